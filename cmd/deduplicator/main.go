@@ -7,6 +7,7 @@ import (
 
 	"github.com/khusa-mahal/backend/internal/config"
 	"github.com/khusa-mahal/backend/internal/repository/mongodb"
+	"github.com/khusa-mahal/backend/internal/repository/redis"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -31,6 +32,16 @@ func main() {
 
 	ctx := context.Background()
 	collection := db.GetDB().Collection("products")
+	productRepo := mongodb.NewProductRepository(db.GetDB())
+
+	// Evict the deleted products from every server's caches
+	cache := redis.NewCache(cfg)
+	if err := cache.Ping(ctx); err != nil {
+		log.Println("⚠️  Redis unavailable, cached products will expire on their own:", err)
+	} else {
+		productRepo.SetListener(redis.NewInvalidator(cache))
+	}
+	defer cache.Close()
 
 	// Find all products
 	cursor, err := collection.Find(ctx, bson.M{})
@@ -78,14 +89,12 @@ func main() {
 	log.Println("🗑️  Deleting duplicates...")
 
 	// Delete duplicates
-	result, err := collection.DeleteMany(ctx, bson.M{
-		"_id": bson.M{"$in": duplicates},
-	})
+	deleted, err := productRepo.DeleteMany(ctx, duplicates)
 	if err != nil {
 		log.Fatal("Failed to delete duplicates:", err)
 	}
 
-	log.Printf("✅ Successfully deleted %d duplicate products\n", result.DeletedCount)
+	log.Printf("✅ Successfully deleted %d duplicate products\n", deleted)
 	log.Printf("📦 Final count: %d unique products\n", len(seenProducts))
 	log.Println("🎉 Deduplication complete!")
 
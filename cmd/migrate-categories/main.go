@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/khusa-mahal/backend/internal/config"
+	"github.com/khusa-mahal/backend/internal/repository/elasticsearch"
+	"github.com/khusa-mahal/backend/internal/repository/mongodb"
+	"github.com/khusa-mahal/backend/internal/repository/redis"
+	"github.com/khusa-mahal/backend/internal/search"
+	"github.com/khusa-mahal/backend/internal/services"
+)
+
+// Creates a category document for every category name stored on products and
+// links those products to it. Safe to run more than once:
+//
+//	go run ./cmd/migrate-categories
+func main() {
+	log.Println("🗂️  Migrating product categories...")
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	// Connect to MongoDB
+	db, err := mongodb.Connect(cfg)
+	if err != nil {
+		log.Fatal("Failed to connect to MongoDB:", err)
+	}
+	defer db.Close(context.Background())
+
+	ctx := context.Background()
+
+	cache := redis.NewCache(cfg)
+	if err := cache.Ping(ctx); err != nil {
+		log.Println("⚠️  Redis unavailable, cached lists will expire on their own:", err)
+	}
+	defer cache.Close()
+
+	searchService, err := elasticsearch.NewSearchService(cfg)
+	if err != nil {
+		log.Println("⚠️  Elasticsearch unavailable, skipping reindex:", err)
+	}
+
+	productRepo := mongodb.NewProductRepository(db.GetDB())
+	productRepo.SetListener(redis.NewInvalidator(cache))
+	categoryRepo := mongodb.NewCategoryRepository(db.GetDB())
+	if err := categoryRepo.CreateIndexes(ctx); err != nil {
+		log.Fatal("Failed to create category indexes:", err)
+	}
+
+	searchBackend, err := search.New(ctx, cfg.Search.Backend, searchService, productRepo)
+	if err != nil {
+		log.Fatal("Failed to initialize search backend:", err)
+	}
+
+	productService := services.NewProductService(productRepo, categoryRepo, cache, searchBackend)
+	categoryService := services.NewCategoryService(categoryRepo, productRepo, productService)
+
+	report, err := categoryService.MigrateProductCategories(ctx)
+	if err != nil {
+		log.Fatal("❌ Migration failed:", err)
+	}
+
+	log.Printf("✅ Linked %d products across %d categories", report.Linked, report.Categories)
+	if report.Sync != nil && !report.Sync.Synced {
+		log.Printf("⚠️  Search not fully synced: %s", report.Sync.SearchError)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/khusa-mahal/backend/internal/config"
+	"github.com/khusa-mahal/backend/internal/repository/mongodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Grants or revokes a role on an existing account, e.g. to bootstrap the first admin:
+//
+//	go run ./cmd/promote -email owner@khusamahal.com
+//	go run ./cmd/promote -email former@khusamahal.com -revoke
+//
+// Revoking also signs the user out everywhere, since their access tokens
+// still carry the role.
+func main() {
+	email := flag.String("email", "", "email of the account to change")
+	role := flag.String("role", "admin", "role to grant or revoke")
+	revoke := flag.Bool("revoke", false, "revoke the role instead of granting it")
+	flag.Parse()
+
+	if *email == "" {
+		flag.Usage()
+		log.Fatal("-email is required")
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	// Connect to MongoDB
+	db, err := mongodb.Connect(cfg)
+	if err != nil {
+		log.Fatal("Failed to connect to MongoDB:", err)
+	}
+	defer db.Close(context.Background())
+
+	ctx := context.Background()
+	userRepo := mongodb.NewUserRepository(db.GetDB())
+
+	if *revoke {
+		err = userRepo.RemoveRole(ctx, *email, *role)
+	} else {
+		err = userRepo.AddRole(ctx, *email, *role)
+	}
+	if err != nil {
+		log.Fatalf("❌ Failed to update roles for %s: %v", *email, err)
+	}
+
+	if !*revoke {
+		log.Printf("✅ Granted %q to %s", *role, *email)
+		log.Printf("ℹ️  It applies once the user's access token is refreshed, within %s", cfg.JWT.Expiry)
+		return
+	}
+	log.Printf("✅ Revoked %q from %s", *role, *email)
+
+	user, err := userRepo.FindByEmail(ctx, *email)
+	if err != nil {
+		log.Fatalf("❌ Failed to find %s to sign them out: %v", *email, err)
+	}
+	sessions, err := mongodb.NewSessionRepository(db.GetDB()).RevokeAllForUser(ctx, user.ID, primitive.NilObjectID, "role revoked")
+	if err != nil {
+		log.Fatalf("❌ Failed to sign out %s: %v", *email, err)
+	}
+	// Servers recheck sessions against MongoDB every few seconds
+	log.Printf("✅ Signed out %d sessions; their access tokens stop working within seconds", len(sessions))
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/khusa-mahal/backend/internal/config"
+	"github.com/khusa-mahal/backend/internal/repository/elasticsearch"
+	"github.com/khusa-mahal/backend/internal/repository/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Rebuilds the product search index without downtime. A new versioned index
+// is filled from MongoDB and checked, then the alias the server uses is
+// switched to it in one step:
+//
+//	go run ./cmd/reindex              # build a new index and switch to it
+//	go run ./cmd/reindex -rollback    # switch back to the previous index
+//	go run ./cmd/reindex -keep 3      # keep three versions instead of two
+func main() {
+	rollback := flag.Bool("rollback", false, "point the alias back at the previous index version")
+	keep := flag.Int("keep", 2, "number of index versions to keep, including the live one (0 keeps all)")
+	flag.Parse()
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	// Connect to MongoDB
+	db, err := mongodb.Connect(cfg)
+	if err != nil {
+		log.Fatal("Failed to connect to MongoDB:", err)
+	}
+	defer db.Close(context.Background())
+
+	searchService, err := elasticsearch.NewSearchService(cfg)
+	if err != nil {
+		log.Fatal("Failed to connect to Elasticsearch:", err)
+	}
+
+	ctx := context.Background()
+	productRepo := mongodb.NewProductRepository(db.GetDB())
+
+	if *rollback {
+		rollbackIndex(ctx, searchService, productRepo)
+		return
+	}
+
+	log.Printf("🔄 Reindexing products into alias %q...", searchService.Alias())
+	started := time.Now()
+
+	products, err := productRepo.GetAll(ctx, bson.M{"isArchived": bson.M{"$ne": true}})
+	if err != nil {
+		log.Fatal("Failed to load products:", err)
+	}
+
+	index, err := searchService.CreateVersionedIndex(ctx)
+	if err != nil {
+		log.Fatal("Failed to create index:", err)
+	}
+	log.Printf("Created %s, indexing %d products", index, len(products))
+
+	if err := searchService.BulkIndex(ctx, index, products); err != nil {
+		discard(ctx, searchService, index)
+		log.Fatal("❌ Bulk indexing failed:", err)
+	}
+
+	count, err := searchService.Count(ctx, index)
+	if err != nil {
+		discard(ctx, searchService, index)
+		log.Fatal("❌ Failed to verify index:", err)
+	}
+	if count != int64(len(products)) {
+		discard(ctx, searchService, index)
+		log.Fatalf("❌ Index holds %d documents, expected %d; alias left unchanged", count, len(products))
+	}
+
+	previous, err := searchService.SwapAlias(ctx, index)
+	if err != nil {
+		discard(ctx, searchService, index)
+		log.Fatal("❌ Failed to switch alias:", err)
+	}
+	log.Printf("✅ Alias %q now points at %s (was %v)", searchService.Alias(), index, previous)
+
+	catchUp(ctx, searchService, productRepo, started)
+	prune(ctx, searchService, *keep)
+}
+
+// rollbackIndex points the alias at the version before the live one, then
+// replays product writes made since the live version was built
+func rollbackIndex(ctx context.Context, searchService *elasticsearch.SearchService, productRepo *mongodb.ProductRepository) {
+	current, previous, err := searchService.PreviousVersion(ctx)
+	if errors.Is(err, elasticsearch.ErrNoPreviousIndex) {
+		log.Fatalf("❌ %s is the oldest index version left; nothing to roll back to", current)
+	}
+	if err != nil {
+		log.Fatal("❌ Failed to find previous index:", err)
+	}
+
+	if _, err := searchService.SwapAlias(ctx, previous); err != nil {
+		log.Fatal("❌ Failed to switch alias:", err)
+	}
+	log.Printf("✅ Alias %q rolled back from %s to %s", searchService.Alias(), current, previous)
+
+	if since, ok := searchService.VersionTime(current); ok {
+		catchUp(ctx, searchService, productRepo, since)
+	}
+}
+
+// catchUp re-syncs products written while an index was being built, since
+// the server kept writing to the old index until the alias moved. Products
+// deleted in that window have to be removed by hand or by the next reindex.
+func catchUp(ctx context.Context, searchService *elasticsearch.SearchService, productRepo *mongodb.ProductRepository, since time.Time) {
+	products, err := productRepo.GetAll(ctx, bson.M{"updatedAt": bson.M{"$gte": since}})
+	if err != nil {
+		log.Println("⚠️  Failed to load recently changed products:", err)
+		return
+	}
+
+	for i := range products {
+		product := &products[i]
+		if product.IsArchived {
+			err = searchService.DeleteProduct(ctx, product.ID.Hex())
+			if errors.Is(err, elasticsearch.ErrNotFound) {
+				err = nil
+			}
+		} else {
+			err = searchService.IndexProduct(ctx, product)
+		}
+		if err != nil {
+			log.Printf("⚠️  Failed to re-sync product %s: %v", product.ID.Hex(), err)
+		}
+	}
+	if len(products) > 0 {
+		log.Printf("Re-synced %d products changed during the switch", len(products))
+	}
+}
+
+// prune deletes the oldest versions beyond keep, never the live one
+func prune(ctx context.Context, searchService *elasticsearch.SearchService, keep int) {
+	if keep <= 0 {
+		return
+	}
+
+	versions, err := searchService.Versions(ctx)
+	if err != nil {
+		log.Println("⚠️  Failed to list index versions:", err)
+		return
+	}
+	live, _, err := searchService.AliasTargets(ctx)
+	if err != nil {
+		log.Println("⚠️  Failed to read alias:", err)
+		return
+	}
+
+	for len(versions) > keep {
+		oldest := versions[0]
+		versions = versions[1:]
+		if len(live) == 1 && live[0] == oldest {
+			continue
+		}
+		if err := searchService.DeleteIndex(ctx, oldest); err != nil {
+			log.Printf("⚠️  Failed to delete %s: %v", oldest, err)
+			continue
+		}
+		log.Printf("🗑️  Deleted old index %s", oldest)
+	}
+}
+
+func discard(ctx context.Context, searchService *elasticsearch.SearchService, index string) {
+	if err := searchService.DeleteIndex(ctx, index); err != nil {
+		log.Printf("⚠️  Failed to delete unfinished index %s: %v", index, err)
+	}
+}
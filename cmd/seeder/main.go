@@ -9,6 +9,7 @@ import (
 	"github.com/khusa-mahal/backend/internal/models"
 	"github.com/khusa-mahal/backend/internal/repository/elasticsearch"
 	"github.com/khusa-mahal/backend/internal/repository/mongodb"
+	"github.com/khusa-mahal/backend/internal/repository/redis"
 )
 
 func main() {
@@ -31,6 +32,16 @@ func main() {
 
 	// Initialize repositories
 	productRepo := mongodb.NewProductRepository(db.GetDB())
+	categoryRepo := mongodb.NewCategoryRepository(db.GetDB())
+
+	// Evict cached listings so running servers pick up the new products
+	cache := redis.NewCache(cfg)
+	if err := cache.Ping(context.Background()); err != nil {
+		log.Println("⚠️  Redis unavailable, cached lists will expire on their own:", err)
+	} else {
+		productRepo.SetListener(redis.NewInvalidator(cache))
+	}
+	defer cache.Close()
 
 	// Create indexes
 	if err := productRepo.CreateIndexes(context.Background()); err != nil {
@@ -38,6 +49,9 @@ func main() {
 	} else {
 		log.Println("✅ MongoDB indexes created")
 	}
+	if err := categoryRepo.CreateIndexes(context.Background()); err != nil {
+		log.Println("⚠️  Failed to create category indexes:", err)
+	}
 
 	// Seed products
 	products := getInitialProducts()
@@ -46,6 +60,16 @@ func main() {
 	log.Printf("📦 Seeding %d products...\n", len(products))
 
 	for i, product := range products {
+		product = withVariants(product)
+
+		category, err := categoryRepo.Ensure(ctx, product.Category)
+		if err != nil {
+			log.Printf("❌ Failed to create category %s: %v\n", product.Category, err)
+			continue
+		}
+		product.Category = category.Name
+		product.CategoryID = category.ID
+
 		// Check if product exists by name? Or just insert?
 		// Repo Create usually generates ID if missing.
 		// Detailed logic: ideally upsert, but for seeding fresh is fine.
@@ -55,8 +79,8 @@ func main() {
 		}
 		log.Printf("✓ Created: %s (ID: %s)\n", product.Name, product.ID.Hex())
 
-		// Update the products slice with the generated ID
-		products[i].ID = product.ID
+		// Update the products slice with the generated ID and variants
+		products[i] = product
 	}
 
 	log.Println("✅ Products seeded successfully!")
@@ -95,6 +119,33 @@ func ptr(f float64) *float64 {
 	return &f
 }
 
+// withVariants spreads a product's seed stock evenly across every size and color
+func withVariants(product models.Product) models.Product {
+	combinations := len(product.Sizes) * len(product.Colors)
+	if combinations == 0 || len(product.Variants) > 0 {
+		return product
+	}
+
+	perVariant, remainder := product.Stock/combinations, product.Stock%combinations
+	product.Variants = make([]models.Variant, 0, combinations)
+	for _, size := range product.Sizes {
+		for _, color := range product.Colors {
+			stock := perVariant
+			if remainder > 0 {
+				stock++
+				remainder--
+			}
+			product.Variants = append(product.Variants, models.Variant{
+				SKU:   models.VariantSKU(size, color.Name),
+				Size:  size,
+				Color: color.Name,
+				Stock: stock,
+			})
+		}
+	}
+	return product
+}
+
 func getInitialProducts() []models.Product {
 	rating4_8 := 4.8
 	reviews24 := 24
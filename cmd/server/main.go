@@ -12,9 +12,11 @@ import (
 	"github.com/khusa-mahal/backend/internal/api/middleware"
 	"github.com/khusa-mahal/backend/internal/api/routes"
 	"github.com/khusa-mahal/backend/internal/config"
+	"github.com/khusa-mahal/backend/internal/models"
 	"github.com/khusa-mahal/backend/internal/repository/elasticsearch"
 	"github.com/khusa-mahal/backend/internal/repository/mongodb"
 	"github.com/khusa-mahal/backend/internal/repository/redis"
+	"github.com/khusa-mahal/backend/internal/search"
 	"github.com/khusa-mahal/backend/internal/services"
 )
 
@@ -42,22 +44,24 @@ func main() {
 
 	log.Println("✅ Connected to MongoDB")
 
-	// Initialize Redis cache
+	// Initialize cache (Redis, in-process, or both per CACHE_MODE)
 	cache := redis.NewCache(cfg)
 	if err := cache.Ping(context.Background()); err != nil {
-		log.Println("⚠️  Redis connection failed, running without cache:", err)
+		log.Println("⚠️  Redis connection failed, skipping it until it recovers:", err)
 	} else {
-		log.Println("✅ Connected to Redis")
+		log.Printf("✅ Cache ready (%s)", cache.Mode())
 	}
 	defer cache.Close()
 
-	// Initialize Elasticsearch
-	searchService, err := elasticsearch.NewSearchService(cfg)
-	if err != nil {
-		log.Println("⚠️  Elasticsearch connection failed, search may be limited:", err)
-	} else {
-		// Create index if it doesn't exist
-		if err := searchService.CreateIndex(context.Background()); err != nil {
+	// Initialize Elasticsearch (only used by the elasticsearch search backend)
+	var searchService *elasticsearch.SearchService
+	if cfg.Search.Backend == search.BackendElasticsearch {
+		searchService, err = elasticsearch.NewSearchService(cfg)
+		if err != nil {
+			log.Println("⚠️  Elasticsearch connection failed, search may be limited:", err)
+			searchService = nil
+		} else if err := searchService.CreateIndex(context.Background()); err != nil {
+			// Create index if it doesn't exist
 			log.Println("⚠️  Failed to create Elasticsearch index:", err)
 		} else {
 			log.Println("✅ Connected to Elasticsearch")
@@ -68,17 +72,37 @@ func main() {
 	productRepo := mongodb.NewProductRepository(db.GetDB())
 	userRepo := mongodb.NewUserRepository(db.GetDB())
 	otpRepo := mongodb.NewOTPRepository(db.GetDB())
+	sessionRepo := mongodb.NewSessionRepository(db.GetDB())
+	throttleRepo := mongodb.NewThrottleRepository(db.GetDB())
 	orderRepo := mongodb.NewOrderRepository(db.GetDB())
 	cartRepo := mongodb.NewCartRepository(db.GetDB())         // [NEW]
 	wishlistRepo := mongodb.NewWishlistRepository(db.GetDB()) // [NEW]
+	reviewRepo := mongodb.NewReviewRepository(db.GetDB())
+	categoryRepo := mongodb.NewCategoryRepository(db.GetDB())
+	synonymRepo := mongodb.NewSynonymRepository(db.GetDB())
+	syncStateRepo := mongodb.NewSyncStateRepository(db.GetDB())
+	searchLogRepo := mongodb.NewSearchLogRepository(db.GetDB())
+
+	// Initialize search backend
+	searchBackend, err := search.New(context.Background(), cfg.Search.Backend, searchService, productRepo)
+	if err != nil {
+		log.Fatal("Failed to initialize search backend:", err)
+	}
+	log.Printf("✅ Search backend: %s", searchBackend.Name())
 
 	// Initialize services
 	emailService := services.NewEmailService()
-	authService := services.NewAuthService(userRepo, otpRepo, emailService)
+	authService := services.NewAuthService(userRepo, otpRepo, sessionRepo, throttleRepo, cache, emailService, cfg.JWT, cfg.OTP)
 	paymentService := services.NewPaymentService()
-	orderService := services.NewOrderService(orderRepo, paymentService, emailService, userRepo, productRepo)
+	orderService := services.NewOrderService(orderRepo, paymentService, emailService, userRepo, productRepo, cfg.Pricing)
 	cartService := services.NewCartService(cartRepo, productRepo)             // [NEW]
 	wishlistService := services.NewWishlistService(wishlistRepo, productRepo) // [NEW]
+	productService := services.NewProductService(productRepo, categoryRepo, cache, searchBackend)
+	userService := services.NewUserService(userRepo)
+	categoryService := services.NewCategoryService(categoryRepo, productRepo, productService)
+	reviewService := services.NewReviewService(reviewRepo, orderRepo, userRepo, productRepo, productService)
+	synonymService := services.NewSynonymService(synonymRepo, searchService)
+	searchAnalytics := services.NewSearchAnalyticsService(searchLogRepo)
 
 	// Create indexes for better performance
 	if err := productRepo.CreateIndexes(context.Background()); err != nil {
@@ -86,13 +110,65 @@ func main() {
 	} else {
 		log.Println("✅ MongoDB indexes created")
 	}
+	if err := sessionRepo.CreateIndexes(context.Background()); err != nil {
+		log.Println("⚠️  Failed to create session indexes:", err)
+	}
+	if err := userRepo.CreateIndexes(context.Background()); err != nil {
+		log.Println("⚠️  Failed to create user indexes (check for duplicate emails):", err)
+	}
+	if err := categoryRepo.CreateIndexes(context.Background()); err != nil {
+		log.Println("⚠️  Failed to create category indexes:", err)
+	}
+	if err := reviewRepo.CreateIndexes(context.Background()); err != nil {
+		log.Println("⚠️  Failed to create review indexes:", err)
+	}
+	if err := throttleRepo.CreateIndexes(context.Background()); err != nil {
+		log.Println("⚠️  Failed to create throttle indexes:", err)
+	}
+	if err := searchLogRepo.CreateIndexes(context.Background(), cfg.Search.LogRetention); err != nil {
+		log.Println("⚠️  Failed to create search log indexes:", err)
+	}
+
+	// Load the managed synonym list into the search analyzer
+	if searchService != nil {
+		if err := synonymService.Init(context.Background()); err != nil {
+			log.Println("⚠️  Failed to load search synonyms:", err)
+		}
+	}
+
+	// Mirror product writes into Elasticsearch in the background
+	syncCtx, stopSync := context.WithCancel(context.Background())
+	defer stopSync()
+	var searchSyncer *services.SearchSyncer
+	if searchService != nil && cfg.Elasticsearch.SyncEnabled {
+		searchSyncer = services.NewSearchSyncer(productRepo, syncStateRepo, searchService)
+		go searchSyncer.Run(syncCtx)
+		log.Println("✅ Search sync started")
+	}
+
+	// Evict cached products on every write and fan the change out to the
+	// other server instances
+	invalidator := redis.NewInvalidator(cache)
+	productRepo.SetListener(invalidator)
+	if searchBackend.Name() == search.BackendMemory {
+		// Each instance holds its own in-memory index
+		invalidator.OnChange(func(ctx context.Context, change models.ProductChange) {
+			productService.SyncProducts(ctx, change.IDs)
+		})
+	}
+	go invalidator.Listen(syncCtx)
 
 	// Initialize handlers
-	productHandler := handlers.NewProductHandler(productRepo, cache, searchService)
+	productHandler := handlers.NewProductHandler(productRepo, cache, productService, searchAnalytics)
 	authHandler := handlers.NewAuthHandler(authService)
 	orderHandler := handlers.NewOrderHandler(orderService)
 	cartHandler := handlers.NewCartHandler(cartService)             // [NEW]
 	wishlistHandler := handlers.NewWishlistHandler(wishlistService) // [NEW]
+	userHandler := handlers.NewUserHandler(userService, authService)
+	reviewHandler := handlers.NewReviewHandler(reviewService)
+	categoryHandler := handlers.NewCategoryHandler(categoryService, cache)
+	searchAdminHandler := handlers.NewSearchAdminHandler(synonymService, searchAnalytics)
+	healthHandler := handlers.NewHealthHandler(searchSyncer)
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
@@ -102,13 +178,24 @@ func main() {
 
 	// Setup middleware
 	middleware.SetupMiddleware(app, cfg)
+	middleware.SetTokenValidator(authService)
 
 	// Setup routes
-	routes.SetupRoutes(app, productHandler)
+	routes.SetupRoutes(app, productHandler, healthHandler, cfg.HTTPCache)
 	routes.RegisterAuthRoutes(app.Group("/api/v1"), authHandler)
 	routes.RegisterOrderRoutes(app.Group("/api/v1"), orderHandler)
 	routes.RegisterCartRoutes(app.Group("/api/v1"), cartHandler)         // [NEW]
 	routes.RegisterWishlistRoutes(app.Group("/api/v1"), wishlistHandler) // [NEW]
+	routes.RegisterUserRoutes(app.Group("/api/v1"), userHandler)
+	routes.RegisterReviewRoutes(app.Group("/api/v1"), reviewHandler)
+	routes.RegisterCategoryRoutes(app.Group("/api/v1"), categoryHandler, cfg.HTTPCache.Categories)
+
+	// Admin routes
+	admin := routes.AdminGroup(app.Group("/api/v1"))
+	routes.RegisterAdminOrderRoutes(admin, orderHandler)
+	routes.RegisterAdminProductRoutes(admin, productHandler)
+	routes.RegisterAdminCategoryRoutes(admin, categoryHandler)
+	routes.RegisterAdminSearchRoutes(admin, searchAdminHandler)
 
 	// Graceful shutdown
 	go func() {
@@ -1,7 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/khusa-mahal/backend/internal/models"
 	"github.com/khusa-mahal/backend/internal/services"
 )
@@ -36,7 +41,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	}
 
 	if err := h.authService.Register(c.Context(), user, req.Password); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return authError(c, err)
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -56,15 +61,17 @@ func (h *AuthHandler) VerifyOTP(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
-	token, user, err := h.authService.VerifyOTP(c.Context(), req.Email, req.Code)
+	tokens, user, err := h.authService.VerifyOTP(c.Context(), req.Email, req.Code, clientInfo(c))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return authError(c, err)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "Email verified successfully.",
-		"token":   token,
-		"user":    user,
+		"message":      "Email verified successfully.",
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+		"user":         user,
 	})
 }
 
@@ -79,13 +86,147 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
-	token, user, err := h.authService.Login(c.Context(), req.Email, req.Password)
+	tokens, user, err := h.authService.Login(c.Context(), req.Email, req.Password, clientInfo(c))
 	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"token": token,
-		"user":  user,
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+		"user":         user,
 	})
 }
+
+type ResendOTPRequest struct {
+	Email string `json:"email"`
+}
+
+// ResendOTP sends a new verification code to an unverified account. The
+// response doesn't reveal whether the email is registered.
+func (h *AuthHandler) ResendOTP(c *fiber.Ctx) error {
+	var req ResendOTPRequest
+	if err := c.BodyParser(&req); err != nil || req.Email == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Email is required"})
+	}
+
+	if err := h.authService.ResendOTP(c.Context(), req.Email); err != nil {
+		return authError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "If this email is awaiting verification, a new code has been sent.",
+	})
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ForgotPassword sends a reset code. The response is identical whether or not
+// the email is registered.
+func (h *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil || req.Email == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Email is required"})
+	}
+
+	if err := h.authService.ForgotPassword(c.Context(), req.Email); err != nil {
+		var rateLimit *services.RateLimitError
+		if errors.As(err, &rateLimit) {
+			return authError(c, err)
+		}
+		fmt.Printf("⚠️  ForgotPassword failed: %v\n", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "If an account exists for this email, a reset code has been sent.",
+	})
+}
+
+type ResetPasswordRequest struct {
+	Email       string `json:"email"`
+	Code        string `json:"code"`
+	NewPassword string `json:"newPassword"`
+}
+
+// ResetPassword sets a new password with a reset code and signs out all sessions
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	var req ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.authService.ResetPassword(c.Context(), req.Email, req.Code, req.NewPassword, clientInfo(c)); err != nil {
+		return authError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Password updated. Please sign in with your new password.",
+	})
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Refresh rotates a refresh token and returns a new token pair
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var req RefreshRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	tokens, user, err := h.authService.Refresh(c.Context(), req.RefreshToken)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+		"user":         user,
+	})
+}
+
+// Logout ends the current session. The refresh token in the body is
+// preferred; otherwise the session of the bearer token is ended.
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	var req RefreshRequest
+	_ = c.BodyParser(&req)
+
+	var sessionID string
+	if userToken, ok := c.Locals("user").(*jwt.Token); ok {
+		claims := userToken.Claims.(jwt.MapClaims)
+		sessionID, _ = claims["sid"].(string)
+	}
+
+	if err := h.authService.Logout(c.Context(), req.RefreshToken, sessionID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Logged out"})
+}
+
+func clientInfo(c *fiber.Ctx) services.ClientInfo {
+	return services.ClientInfo{
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IP:        c.IP(),
+	}
+}
+
+// authError answers rate-limited requests with 429 and a Retry-After header
+// and everything else with 400
+func authError(c *fiber.Ctx, err error) error {
+	var rateLimit *services.RateLimitError
+	if errors.As(err, &rateLimit) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(rateLimit.RetryAfterSeconds()))
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error":      rateLimit.Error(),
+			"retryAfter": rateLimit.RetryAfterSeconds(),
+		})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/khusa-mahal/backend/internal/models"
+	"github.com/khusa-mahal/backend/internal/repository/redis"
+	"github.com/khusa-mahal/backend/internal/services"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type CategoryHandler struct {
+	categoryService *services.CategoryService
+	cache           *redis.Cache
+}
+
+func NewCategoryHandler(categoryService *services.CategoryService, cache *redis.Cache) *CategoryHandler {
+	return &CategoryHandler{
+		categoryService: categoryService,
+		cache:           cache,
+	}
+}
+
+// GetCategories lists every category
+func (h *CategoryHandler) GetCategories(c *fiber.Ctx) error {
+	categories, err := h.categoryService.List(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch categories"})
+	}
+
+	return sendCacheable(c, fiber.Map{
+		"success": true,
+		"data":    categories,
+	}, time.Time{})
+}
+
+// GetCategoryProducts lists the live products in a category
+func (h *CategoryHandler) GetCategoryProducts(c *fiber.Ctx) error {
+	// The cache key and the lookup must agree on the slug
+	slug := models.Slugify(c.Params("slug"))
+
+	products, cached, err := h.cache.FetchCategoryProducts(c.Context(), slug, func(ctx context.Context) (*[]models.Product, error) {
+		_, products, err := h.categoryService.ProductsBySlug(ctx, slug)
+		if err != nil {
+			return nil, err
+		}
+		return &products, nil
+	})
+	if err != nil {
+		return categoryError(c, err)
+	}
+
+	return sendCacheable(c, fiber.Map{
+		"success": true,
+		"data":    products,
+		"cached":  cached,
+	}, time.Time{})
+}
+
+// CreateCategory adds a category
+func (h *CategoryHandler) CreateCategory(c *fiber.Ctx) error {
+	var input services.CategoryInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	category, err := h.categoryService.Create(c.Context(), input)
+	if err != nil {
+		return categoryError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    category,
+	})
+}
+
+// UpdateCategory edits a category; renaming it updates its products
+func (h *CategoryHandler) UpdateCategory(c *fiber.Ctx) error {
+	var input services.CategoryInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	category, report, err := h.categoryService.Update(c.Context(), c.Params("id"), input)
+	if err != nil {
+		return categoryError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    category,
+		"sync":    report,
+	})
+}
+
+// DeleteCategory removes a category with no products
+func (h *CategoryHandler) DeleteCategory(c *fiber.Ctx) error {
+	if err := h.categoryService.Delete(c.Context(), c.Params("id")); err != nil {
+		return categoryError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Category deleted",
+	})
+}
+
+func categoryError(c *fiber.Ctx, err error) error {
+	var validation *services.ValidationError
+	if errors.As(err, &validation) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": validation.Error(), "fields": validation.Fields})
+	}
+
+	switch {
+	case errors.Is(err, services.ErrCategoryExists), errors.Is(err, services.ErrCategoryInUse):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Category not found"})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
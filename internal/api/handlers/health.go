@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/khusa-mahal/backend/internal/services"
+)
+
+type HealthHandler struct {
+	searchSync *services.SearchSyncer // nil when search sync is disabled
+}
+
+func NewHealthHandler(searchSync *services.SearchSyncer) *HealthHandler {
+	return &HealthHandler{
+		searchSync: searchSync,
+	}
+}
+
+// Health reports that the server is up, along with background worker state
+func (h *HealthHandler) Health(c *fiber.Ctx) error {
+	searchSync := services.SearchSyncStatus{Status: "disabled"}
+	if h.searchSync != nil {
+		searchSync = h.searchSync.Status()
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "healthy",
+		"searchSync": searchSync,
+	})
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// sendCacheable sends body as JSON with a strong ETag over its content and,
+// when modified is known, a Last-Modified header. If the request's
+// If-None-Match or If-Modified-Since shows the client already has this
+// version, a bodiless 304 is sent instead. The "cached" flag is left out of
+// the ETag, since it differs between otherwise identical responses.
+//
+// Collections pass a zero modified: the newest item's time doesn't move when
+// an item is removed, so only the ETag can tell their versions apart.
+func sendCacheable(c *fiber.Ctx, body fiber.Map, modified time.Time) error {
+	content := make(fiber.Map, len(body))
+	for key, value := range body {
+		if key != "cached" {
+			content[key] = value
+		}
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Set(fiber.HeaderETag, etag)
+	if !modified.IsZero() {
+		c.Set(fiber.HeaderLastModified, modified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(c, etag, modified) {
+		return c.Status(fiber.StatusNotModified).Send(nil)
+	}
+	return c.JSON(body)
+}
+
+// notModified evaluates the request's conditional headers. If-None-Match
+// takes precedence; If-Modified-Since is only consulted without it.
+func notModified(c *fiber.Ctx, etag string, modified time.Time) bool {
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			// If-None-Match uses the weak comparison
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if since := c.Get(fiber.HeaderIfModifiedSince); since != "" && !modified.IsZero() {
+		sinceTime, err := http.ParseTime(since)
+		// HTTP dates have whole seconds
+		return err == nil && !modified.Truncate(time.Second).After(sinceTime)
+	}
+	return false
+}
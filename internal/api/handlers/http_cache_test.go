@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var testModified = time.Date(2024, 5, 1, 12, 30, 15, 500_000_000, time.UTC)
+
+// cacheableApp serves the same body on every request, with cached set to
+// alternate values the way hits and misses do
+func cacheableApp(modified time.Time) *fiber.App {
+	app := fiber.New()
+	hit := false
+	app.Get("/", func(c *fiber.Ctx) error {
+		hit = !hit
+		return sendCacheable(c, fiber.Map{"success": true, "data": []string{"velvet"}, "cached": hit}, modified)
+	})
+	return app
+}
+
+func get(t *testing.T, app *fiber.App, headers map[string]string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	return res
+}
+
+func TestSendCacheableETag(t *testing.T) {
+	app := cacheableApp(time.Time{})
+
+	first := get(t, app, nil)
+	etag := first.Header.Get(fiber.HeaderETag)
+	if first.StatusCode != fiber.StatusOK || etag == "" {
+		t.Fatalf("first response = %d with ETag %q, want 200 with an ETag", first.StatusCode, etag)
+	}
+	if got := first.Header.Get(fiber.HeaderLastModified); got != "" {
+		t.Errorf("Last-Modified = %q, want none for a collection", got)
+	}
+
+	// The cached flag flips between the two, which mustn't change the ETag
+	second := get(t, app, nil)
+	if got := second.Header.Get(fiber.HeaderETag); got != etag {
+		t.Errorf("second ETag = %q, want %q", got, etag)
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        int
+	}{
+		{"matching", etag, fiber.StatusNotModified},
+		{"weak match", "W/" + etag, fiber.StatusNotModified},
+		{"one of several", `"stale", ` + etag, fiber.StatusNotModified},
+		{"any", "*", fiber.StatusNotModified},
+		{"stale", `"stale"`, fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := get(t, app, map[string]string{fiber.HeaderIfNoneMatch: tt.ifNoneMatch})
+			if res.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", res.StatusCode, tt.want)
+			}
+			body, _ := io.ReadAll(res.Body)
+			if tt.want == fiber.StatusNotModified && len(body) != 0 {
+				t.Errorf("304 body = %q, want none", body)
+			}
+			if got := res.Header.Get(fiber.HeaderETag); got != etag {
+				t.Errorf("ETag = %q, want %q", got, etag)
+			}
+		})
+	}
+}
+
+func TestSendCacheableLastModified(t *testing.T) {
+	app := cacheableApp(testModified)
+
+	res := get(t, app, nil)
+	if got, want := res.Header.Get(fiber.HeaderLastModified), "Wed, 01 May 2024 12:30:15 GMT"; got != want {
+		t.Fatalf("Last-Modified = %q, want %q", got, want)
+	}
+	etag := res.Header.Get(fiber.HeaderETag)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		// The sub-second part of modified is dropped, as in the header
+		{"same second", map[string]string{fiber.HeaderIfModifiedSince: "Wed, 01 May 2024 12:30:15 GMT"}, fiber.StatusNotModified},
+		{"later", map[string]string{fiber.HeaderIfModifiedSince: "Thu, 02 May 2024 00:00:00 GMT"}, fiber.StatusNotModified},
+		{"earlier", map[string]string{fiber.HeaderIfModifiedSince: "Wed, 01 May 2024 12:30:14 GMT"}, fiber.StatusOK},
+		{"malformed", map[string]string{fiber.HeaderIfModifiedSince: "yesterday"}, fiber.StatusOK},
+		// If-None-Match takes precedence over If-Modified-Since
+		{"stale ETag wins", map[string]string{
+			fiber.HeaderIfNoneMatch:     `"stale"`,
+			fiber.HeaderIfModifiedSince: "Thu, 02 May 2024 00:00:00 GMT",
+		}, fiber.StatusOK},
+		{"matching ETag wins", map[string]string{
+			fiber.HeaderIfNoneMatch:     etag,
+			fiber.HeaderIfModifiedSince: "Wed, 01 May 2024 12:30:14 GMT",
+		}, fiber.StatusNotModified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if res := get(t, app, tt.headers); res.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", res.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendCacheableIgnoresIfModifiedSinceForCollections(t *testing.T) {
+	app := cacheableApp(time.Time{})
+
+	res := get(t, app, map[string]string{fiber.HeaderIfModifiedSince: "Thu, 02 May 2024 00:00:00 GMT"})
+	if res.StatusCode != fiber.StatusOK {
+		t.Errorf("status = %d, want 200", res.StatusCode)
+	}
+}
@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/khusa-mahal/backend/internal/models"
 	"github.com/khusa-mahal/backend/internal/services"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type OrderHandler struct {
@@ -30,6 +34,7 @@ type PaymentDetailsRequest struct {
 
 type CreateOrderRequest struct {
 	Items           []OrderRequestItem    `json:"items"`
+	AddressID       string                `json:"addressId"` // Saved address; replaces shippingAddress
 	ShippingAddress models.Address        `json:"shippingAddress"`
 	PaymentMethod   string                `json:"paymentMethod"`
 	PaymentDetails  PaymentDetailsRequest `json:"paymentDetails"`
@@ -49,48 +54,27 @@ func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body: " + err.Error()})
 	}
 
-	// Transform/Validate Items
-	var cartItems []models.CartItem
-	for _, item := range req.Items {
-		idStr := item.ProductID
-		if idStr == "" {
-			idStr = item.ID // Fallback to "id"
-		}
-
-		pid, err := primitive.ObjectIDFromHex(idStr)
-		if err != nil {
-			continue // Skip invalid items or return error? Skipping for now to avoid crash
-		}
-
-		cartItems = append(cartItems, models.CartItem{
-			ProductID:     pid,
-			Quantity:      item.Quantity,
-			SelectedSize:  item.SelectedSize,
-			SelectedColor: item.SelectedColor,
-		})
-	}
-
-	if len(cartItems) == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "No valid items in order"})
+	cartItems, err := toCartItems(req.Items)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	paymentDetails := map[string]interface{}{
 		"walletPhone": req.PaymentDetails.WalletPhone,
 	}
 
-	order, err := h.orderService.CreateOrder(
-		c.Context(),
-		userID,
-		cartItems,
-		req.ShippingAddress,
-		req.PaymentMethod,
-		paymentDetails,
-		req.SubTotal,
-		req.ShippingCost,
-		req.Total,
-	)
+	order, err := h.orderService.CreateOrder(c.Context(), userID, services.CreateOrderInput{
+		Items:           cartItems,
+		AddressID:       req.AddressID,
+		ShippingAddress: req.ShippingAddress,
+		PaymentMethod:   req.PaymentMethod,
+		PaymentDetails:  paymentDetails,
+		SubTotal:        req.SubTotal,
+		ShippingCost:    req.ShippingCost,
+		Total:           req.Total,
+	})
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return orderError(c, err)
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -99,6 +83,32 @@ func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
 	})
 }
 
+// QuoteOrder returns the server-side prices for a prospective order so the
+// client can display the exact totals CreateOrder will accept
+func (h *OrderHandler) QuoteOrder(c *fiber.Ctx) error {
+	var req struct {
+		Items []OrderRequestItem `json:"items"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	cartItems, err := toCartItems(req.Items)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	quote, err := h.orderService.QuoteOrder(c.Context(), cartItems)
+	if err != nil {
+		return orderError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    quote,
+	})
+}
+
 func (h *OrderHandler) GetOrders(c *fiber.Ctx) error {
 	// 1. Get User ID from JWT (set by middleware in Locals)
 	userToken := c.Locals("user").(*jwt.Token)
@@ -116,3 +126,164 @@ func (h *OrderHandler) GetOrders(c *fiber.Ctx) error {
 		"data":    orders,
 	})
 }
+
+// GetOrder returns one of the current user's orders with its status timeline
+func (h *OrderHandler) GetOrder(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*jwt.Token)
+	claims := userToken.Claims.(jwt.MapClaims)
+	userID := claims["userId"].(string)
+
+	order, err := h.orderService.GetUserOrder(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return orderError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    order,
+	})
+}
+
+// CancelOrder cancels one of the current user's orders and restocks its items
+func (h *OrderHandler) CancelOrder(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*jwt.Token)
+	claims := userToken.Claims.(jwt.MapClaims)
+	userID := claims["userId"].(string)
+
+	order, err := h.orderService.CancelOrder(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return orderError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    order,
+	})
+}
+
+// AdminListOrders lists recent orders, optionally filtered by ?status=
+func (h *OrderHandler) AdminListOrders(c *fiber.Ctx) error {
+	limit := int64(c.QueryInt("limit", 50))
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	orders, err := h.orderService.ListOrders(c.Context(), c.Query("status"), limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch orders"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    orders,
+	})
+}
+
+// AdminGetOrder returns any order with its timeline
+func (h *OrderHandler) AdminGetOrder(c *fiber.Ctx) error {
+	order, err := h.orderService.GetOrder(c.Context(), c.Params("id"))
+	if err != nil {
+		return orderError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    order,
+	})
+}
+
+type UpdateOrderStatusRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+// AdminUpdateStatus moves an order through the fulfilment state machine
+func (h *OrderHandler) AdminUpdateStatus(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*jwt.Token)
+	claims := userToken.Claims.(jwt.MapClaims)
+	adminID := claims["userId"].(string)
+
+	var req UpdateOrderStatusRequest
+	if err := c.BodyParser(&req); err != nil || req.Status == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "status is required"})
+	}
+
+	actor := services.OrderActor{ID: adminID, Role: services.ActorRoleAdmin}
+	order, err := h.orderService.TransitionStatus(c.Context(), c.Params("id"), req.Status, actor, req.Note)
+	if err != nil {
+		return orderError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    order,
+	})
+}
+
+// toCartItems converts request lines into cart items, rejecting malformed product IDs
+func toCartItems(items []OrderRequestItem) ([]models.CartItem, error) {
+	var cartItems []models.CartItem
+	for i, item := range items {
+		idStr := item.ProductID
+		if idStr == "" {
+			idStr = item.ID // Fallback to "id"
+		}
+
+		pid, err := primitive.ObjectIDFromHex(idStr)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: invalid product ID", i)
+		}
+
+		cartItems = append(cartItems, models.CartItem{
+			ProductID:     pid,
+			Quantity:      item.Quantity,
+			SelectedSize:  item.SelectedSize,
+			SelectedColor: item.SelectedColor,
+		})
+	}
+
+	if len(cartItems) == 0 {
+		return nil, errors.New("No valid items in order")
+	}
+	return cartItems, nil
+}
+
+// orderError maps order service errors to HTTP responses
+func orderError(c *fiber.Ctx, err error) error {
+	var itemErr *services.InvalidOrderItemError
+	if errors.As(err, &itemErr) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": itemErr.Error(), "item": itemErr})
+	}
+
+	var mismatch *services.PriceMismatchError
+	if errors.As(err, &mismatch) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":    mismatch.Error(),
+			"expected": mismatch.Expected,
+			"received": mismatch.Received,
+		})
+	}
+
+	var outOfStock *services.OutOfStockError
+	if errors.As(err, &outOfStock) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":      outOfStock.Error(),
+			"outOfStock": outOfStock.Items,
+		})
+	}
+
+	if errors.Is(err, services.ErrAddressNotFound) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Saved address not found"})
+	}
+
+	var transition *services.InvalidTransitionError
+	if errors.As(err, &transition) || errors.Is(err, services.ErrOrderStatusConflict) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Order not found"})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
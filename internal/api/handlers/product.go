@@ -2,57 +2,52 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/khusa-mahal/backend/internal/repository/elasticsearch"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/khusa-mahal/backend/internal/models"
 	"github.com/khusa-mahal/backend/internal/repository/mongodb"
 	"github.com/khusa-mahal/backend/internal/repository/redis"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/khusa-mahal/backend/internal/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type ProductHandler struct {
-	repo   *mongodb.ProductRepository
-	cache  *redis.Cache
-	search *elasticsearch.SearchService
+	repo      *mongodb.ProductRepository
+	cache     *redis.Cache
+	products  *services.ProductService
+	analytics *services.SearchAnalyticsService
 }
 
-func NewProductHandler(repo *mongodb.ProductRepository, cache *redis.Cache, search *elasticsearch.SearchService) *ProductHandler {
+func NewProductHandler(repo *mongodb.ProductRepository, cache *redis.Cache, products *services.ProductService, analytics *services.SearchAnalyticsService) *ProductHandler {
 	return &ProductHandler{
-		repo:   repo,
-		cache:  cache,
-		search: search,
+		repo:      repo,
+		cache:     cache,
+		products:  products,
+		analytics: analytics,
 	}
 }
 
-// GetProducts retrieves all products with optional filters
+// GetProducts lists live products a page at a time with optional filters:
+// category, minPrice, maxPrice, size and color (comma-separated), isSale,
+// isNew, inStock, sort, and page/limit or cursor
 func (h *ProductHandler) GetProducts(c *fiber.Ctx) error {
-	ctx := context.Background()
-	category := c.Query("category")
-	filter := "all"
+	ctx := c.Context()
 
-	if category != "" {
-		filter = category
-	}
-
-	// Try cache first
-	products, err := h.cache.GetProductList(ctx, filter)
+	query, err := parseProductQuery(c)
 	if err == nil {
-		return c.JSON(fiber.Map{
-			"success": true,
-			"data":    products,
-			"cached":  true,
-		})
+		err = query.Normalize()
 	}
-
-	// Cache miss - fetch from database
-	var dbFilter bson.M
-	if category != "" {
-		dbFilter = bson.M{"category": category}
-	} else {
-		dbFilter = bson.M{}
+	if err != nil {
+		return productError(c, err)
 	}
 
-	products, err = h.repo.GetAll(ctx, dbFilter)
+	result, cached, err := h.products.ListProducts(ctx, *query)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"success": false,
@@ -60,71 +55,249 @@ func (h *ProductHandler) GetProducts(c *fiber.Ctx) error {
 		})
 	}
 
-	// Cache the result
-	_ = h.cache.SetProductList(ctx, filter, products)
-
-	return c.JSON(fiber.Map{
+	return sendCacheable(c, fiber.Map{
 		"success": true,
-		"data":    products,
-		"cached":  false,
-	})
+		"data":    result.Products,
+		"pagination": fiber.Map{
+			"page":       result.Page,
+			"limit":      result.Limit,
+			"total":      result.Total,
+			"totalPages": result.TotalPages,
+			"nextCursor": result.NextCursor,
+		},
+		"cached": cached,
+	}, time.Time{})
+}
+
+func parseProductQuery(c *fiber.Ctx) (*services.ProductQuery, error) {
+	fields := map[string]string{}
+	query := &services.ProductQuery{
+		Category: c.Query("category"),
+		Sizes:    splitList(c.Query("size")),
+		Colors:   splitList(c.Query("color")),
+		InStock:  c.QueryBool("inStock", false),
+		Sort:     c.Query("sort"),
+		Page:     c.QueryInt("page", 1),
+		Limit:    c.QueryInt("limit", 0),
+	}
+
+	queryFloats(c, fields, map[string]**float64{"minPrice": &query.MinPrice, "maxPrice": &query.MaxPrice})
+	queryBools(c, fields, map[string]**bool{"isSale": &query.IsSale, "isNew": &query.IsNew})
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		page, err := services.DecodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		query.Page = page
+	}
+
+	if len(fields) > 0 {
+		return nil, &services.ValidationError{Fields: fields}
+	}
+	return query, nil
+}
+
+// queryFloats parses optional numeric query parameters into dests, recording
+// malformed ones in fields
+func queryFloats(c *fiber.Ctx, fields map[string]string, dests map[string]**float64) {
+	for name, dest := range dests {
+		if raw := c.Query(name); raw != "" {
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				fields[name] = name + " must be a number"
+				continue
+			}
+			*dest = &value
+		}
+	}
+}
+
+// queryBools parses optional boolean query parameters into dests, recording
+// malformed ones in fields
+func queryBools(c *fiber.Ctx, fields map[string]string, dests map[string]**bool) {
+	for name, dest := range dests {
+		if raw := c.Query(name); raw != "" {
+			value, err := strconv.ParseBool(raw)
+			if err != nil {
+				fields[name] = name + " must be true or false"
+				continue
+			}
+			*dest = &value
+		}
+	}
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
 }
 
 // GetProduct retrieves a single product by ID
 func (h *ProductHandler) GetProduct(c *fiber.Ctx) error {
-	ctx := context.Background()
+	ctx := c.Context()
 	id := c.Params("id")
 
-	// Try cache first
-	product, err := h.cache.GetProduct(ctx, id)
-	if err == nil {
-		return c.JSON(fiber.Map{
-			"success": true,
-			"data":    product,
-			"cached":  true,
+	// Malformed IDs can't exist, so they never reach the cache or database
+	if !primitive.IsValidObjectID(id) {
+		return c.Status(404).JSON(fiber.Map{
+			"success": false,
+			"error":   "Product not found",
 		})
 	}
 
-	// Cache miss - fetch from database
-	product, err = h.repo.GetByID(ctx, id)
+	// Unknown and archived products are cached as missing, so repeated
+	// requests for them don't reach the database either
+	product, cached, err := h.cache.FetchProduct(ctx, id, func(ctx context.Context) (*models.Product, error) {
+		product, err := h.repo.GetByID(ctx, id)
+		if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && product.IsArchived) {
+			return nil, nil
+		}
+		return product, err
+	})
 	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to fetch product",
+		})
+	}
+	if product == nil {
 		return c.Status(404).JSON(fiber.Map{
 			"success": false,
 			"error":   "Product not found",
 		})
 	}
 
-	// Cache the result
-	_ = h.cache.SetProduct(ctx, id, product)
-
-	return c.JSON(fiber.Map{
+	return sendCacheable(c, fiber.Map{
 		"success": true,
 		"data":    product,
-		"cached":  false,
-	})
+		"cached":  cached,
+	}, product.UpdatedAt)
 }
 
-// SearchProducts searches products using Elasticsearch
+// SearchProducts runs a faceted full-text search. Elasticsearch is used when
+// available; otherwise MongoDB serves the same response shape.
 func (h *ProductHandler) SearchProducts(c *fiber.Ctx) error {
-	ctx := context.Background()
-	query := c.Query("q")
-	if query == "" {
-		return c.Status(400).JSON(fiber.Map{
+	fields := map[string]string{}
+	query := models.SearchQuery{
+		Text:     c.Query("q"),
+		Category: c.Query("category"),
+		Sizes:    splitList(c.Query("size")),
+		Colors:   splitList(c.Query("color")),
+		Page:     c.QueryInt("page", 1),
+		Limit:    c.QueryInt("limit", 0),
+	}
+	queryFloats(c, fields, map[string]**float64{"minPrice": &query.MinPrice, "maxPrice": &query.MaxPrice})
+	queryBools(c, fields, map[string]**bool{"isSale": &query.IsSale})
+	if len(fields) > 0 {
+		return productError(c, &services.ValidationError{Fields: fields})
+	}
+
+	result, err := h.products.Search(c.Context(), query)
+	if err != nil {
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			return productError(c, err)
+		}
+		return c.Status(500).JSON(fiber.Map{
 			"success": false,
-			"error":   "Search query is required",
+			"error":   "Search failed",
 		})
 	}
 
-	products, err := h.search.SearchProducts(ctx, query, 0, 50)
-	if err != nil {
-		// Fallback to MongoDB search
-		products, err = h.repo.Search(ctx, query)
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{
-				"success": false,
-				"error":   "Search failed",
-			})
+	totalPages := (result.Total + int64(result.Limit) - 1) / int64(result.Limit)
+
+	// Log each search once, on its first page; later pages and clicks refer
+	// back to it through queryId
+	queryID := c.Query("queryId")
+	if result.Page == 1 {
+		queryID = h.analytics.Record(c.Context(), services.SearchEvent{
+			Query:       query.Text,
+			Filtered:    query.Category != "" || query.Sizes != nil || query.Colors != nil || query.MinPrice != nil || query.MaxPrice != nil || query.IsSale != nil,
+			ResultCount: result.Total,
+			UserID:      optionalUserID(c),
+			SessionID:   c.Get("X-Session-ID"),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"queryId":    queryID,
+		"data":       result.Products,
+		"total":      result.Total,
+		"facets":     result.Facets,
+		"highlights": result.Highlights,
+		"pagination": fiber.Map{
+			"page":       result.Page,
+			"limit":      result.Limit,
+			"total":      result.Total,
+			"totalPages": totalPages,
+		},
+	})
+}
+
+type searchClickRequest struct {
+	QueryID   string `json:"queryId"`
+	ProductID string `json:"productId"`
+}
+
+// RecordSearchClick attributes a click on a search result to the search that
+// returned it
+func (h *ProductHandler) RecordSearchClick(c *fiber.Ctx) error {
+	var req searchClickRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	if err := h.analytics.RecordClick(c.Context(), req.QueryID, req.ProductID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Search not found"})
 		}
+		return productError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// optionalUserID returns the signed-in user's ID on routes using OptionalAuth,
+// or an empty string for guests
+func optionalUserID(c *fiber.Ctx) string {
+	token, ok := c.Locals("user").(*jwt.Token)
+	if !ok {
+		return ""
+	}
+	userID, _ := token.Claims.(jwt.MapClaims)["userId"].(string)
+	return userID
+}
+
+// SuggestProducts returns up to 10 autocomplete suggestions for the search box
+func (h *ProductHandler) SuggestProducts(c *fiber.Ctx) error {
+	suggestions, err := h.products.Suggest(c.Context(), c.Query("q"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"error":   "Suggest failed",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    suggestions,
+	})
+}
+
+// Admin product management
+
+// AdminListProducts returns every product, including archived ones
+func (h *ProductHandler) AdminListProducts(c *fiber.Ctx) error {
+	products, err := h.products.ListAll(c.Context())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to fetch products",
+		})
 	}
 
 	return c.JSON(fiber.Map{
@@ -132,3 +305,100 @@ func (h *ProductHandler) SearchProducts(c *fiber.Ctx) error {
 		"data":    products,
 	})
 }
+
+// CreateProduct adds a product to the catalogue
+func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
+	var input services.ProductInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	product, report, err := h.products.CreateProduct(c.Context(), input)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    product,
+		"sync":    report,
+	})
+}
+
+// UpdateProduct replaces a product's editable fields
+func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
+	var input services.ProductInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	product, report, err := h.products.UpdateProduct(c.Context(), c.Params("id"), input)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    product,
+		"sync":    report,
+	})
+}
+
+// ArchiveProduct hides a product from the storefront and search
+func (h *ProductHandler) ArchiveProduct(c *fiber.Ctx) error {
+	return h.setArchived(c, true)
+}
+
+// RestoreProduct makes an archived product visible again
+func (h *ProductHandler) RestoreProduct(c *fiber.Ctx) error {
+	return h.setArchived(c, false)
+}
+
+func (h *ProductHandler) setArchived(c *fiber.Ctx, archived bool) error {
+	product, report, err := h.products.SetArchived(c.Context(), c.Params("id"), archived)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    product,
+		"sync":    report,
+	})
+}
+
+// DeleteProduct permanently removes a product
+func (h *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
+	report, err := h.products.DeleteProduct(c.Context(), c.Params("id"))
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Product deleted",
+		"sync":    report,
+	})
+}
+
+// productError maps product service errors to HTTP responses
+func productError(c *fiber.Ctx, err error) error {
+	var validation *services.ValidationError
+	if errors.As(err, &validation) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   validation.Error(),
+			"fields":  validation.Fields,
+		})
+	}
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Product not found"})
+	}
+
+	if errors.Is(err, services.ErrProductUpdateConflict) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+}
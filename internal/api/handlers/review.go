@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/khusa-mahal/backend/internal/services"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ReviewHandler struct {
+	reviewService *services.ReviewService
+}
+
+func NewReviewHandler(reviewService *services.ReviewService) *ReviewHandler {
+	return &ReviewHandler{reviewService: reviewService}
+}
+
+// GetReviews returns a page of a product's reviews (?page=1&limit=10)
+func (h *ReviewHandler) GetReviews(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 10)
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	result, err := h.reviewService.ListReviews(c.Context(), c.Params("id"), page, limit)
+	if err != nil {
+		return reviewError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    result.Reviews,
+		"pagination": fiber.Map{
+			"page":       result.Page,
+			"limit":      result.Limit,
+			"total":      result.Total,
+			"totalPages": result.TotalPages,
+		},
+	})
+}
+
+// CreateReview adds the current user's review of a product they have received
+func (h *ReviewHandler) CreateReview(c *fiber.Ctx) error {
+	var input services.ReviewInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	review, err := h.reviewService.CreateReview(c.Context(), currentUserID(c), c.Params("id"), input)
+	if err != nil {
+		return reviewError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    review,
+	})
+}
+
+func reviewError(c *fiber.Ctx, err error) error {
+	var validation *services.ValidationError
+	if errors.As(err, &validation) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": validation.Error(), "fields": validation.Fields})
+	}
+
+	switch {
+	case errors.Is(err, services.ErrNotVerifiedPurchase):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrAlreadyReviewed):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Product not found"})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
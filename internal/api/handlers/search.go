@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/khusa-mahal/backend/internal/services"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SearchAdminHandler serves search tuning endpoints for admins
+type SearchAdminHandler struct {
+	synonymService *services.SynonymService
+	analytics      *services.SearchAnalyticsService
+}
+
+func NewSearchAdminHandler(synonymService *services.SynonymService, analytics *services.SearchAnalyticsService) *SearchAdminHandler {
+	return &SearchAdminHandler{
+		synonymService: synonymService,
+		analytics:      analytics,
+	}
+}
+
+type synonymRequest struct {
+	Terms []string `json:"terms"`
+}
+
+// GetSynonyms lists the synonym groups
+func (h *SearchAdminHandler) GetSynonyms(c *fiber.Ctx) error {
+	groups, err := h.synonymService.List(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch synonyms"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    groups,
+	})
+}
+
+// CreateSynonyms adds a synonym group
+func (h *SearchAdminHandler) CreateSynonyms(c *fiber.Ctx) error {
+	var req synonymRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	group, report, err := h.synonymService.Create(c.Context(), req.Terms)
+	if err != nil {
+		return searchAdminError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    group,
+		"sync":    report,
+	})
+}
+
+// UpdateSynonyms replaces the terms of a synonym group
+func (h *SearchAdminHandler) UpdateSynonyms(c *fiber.Ctx) error {
+	var req synonymRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	group, report, err := h.synonymService.Update(c.Context(), c.Params("id"), req.Terms)
+	if err != nil {
+		return searchAdminError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    group,
+		"sync":    report,
+	})
+}
+
+// DeleteSynonyms removes a synonym group
+func (h *SearchAdminHandler) DeleteSynonyms(c *fiber.Ctx) error {
+	report, err := h.synonymService.Delete(c.Context(), c.Params("id"))
+	if err != nil {
+		return searchAdminError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Synonym group deleted",
+		"sync":    report,
+	})
+}
+
+// ReloadSynonyms pushes the stored synonym list to Elasticsearch again, e.g.
+// after Elasticsearch was unavailable during an edit
+func (h *SearchAdminHandler) ReloadSynonyms(c *fiber.Ctx) error {
+	report := h.synonymService.Reload(c.Context())
+	status := fiber.StatusOK
+	if !report.Synced {
+		status = fiber.StatusServiceUnavailable
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"success": report.Synced,
+		"sync":    report,
+	})
+}
+
+// GetAnalytics reports search volume, click-through and zero-result rates,
+// and the top and zero-result terms between from and to (YYYY-MM-DD, both
+// inclusive, or RFC 3339 times). The default range is the last 30 days.
+func (h *SearchAdminHandler) GetAnalytics(c *fiber.Ctx) error {
+	fields := map[string]string{}
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+
+	if raw := c.Query("from"); raw != "" {
+		if t, _, err := parseReportTime(raw); err == nil {
+			from = t
+		} else {
+			fields["from"] = "from must be a date (YYYY-MM-DD) or RFC 3339 time"
+		}
+	}
+	if raw := c.Query("to"); raw != "" {
+		if t, dateOnly, err := parseReportTime(raw); err == nil {
+			to = t
+			if dateOnly {
+				to = to.AddDate(0, 0, 1)
+			}
+		} else {
+			fields["to"] = "to must be a date (YYYY-MM-DD) or RFC 3339 time"
+		}
+	}
+	if len(fields) > 0 {
+		return searchAdminError(c, &services.ValidationError{Fields: fields})
+	}
+
+	report, err := h.analytics.Report(c.Context(), from, to, c.QueryInt("limit", 0))
+	if err != nil {
+		return searchAdminError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// parseReportTime accepts a date or an RFC 3339 time, reporting which it was
+func parseReportTime(raw string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	return t, false, err
+}
+
+func searchAdminError(c *fiber.Ctx, err error) error {
+	var validation *services.ValidationError
+	if errors.As(err, &validation) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": validation.Error(), "fields": validation.Fields})
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Synonym group not found"})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/khusa-mahal/backend/internal/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type UserHandler struct {
+	userService *services.UserService
+	authService *services.AuthService
+}
+
+func NewUserHandler(userService *services.UserService, authService *services.AuthService) *UserHandler {
+	return &UserHandler{
+		userService: userService,
+		authService: authService,
+	}
+}
+
+// GetProfile returns the signed-in user's profile and address book
+func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
+	user, err := h.userService.GetProfile(c.Context(), currentUserID(c))
+	if err != nil {
+		return userError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    user,
+	})
+}
+
+// UpdateProfile changes any of name, phone and age
+func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
+	var input services.ProfileInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	user, err := h.userService.UpdateProfile(c.Context(), currentUserID(c), input)
+	if err != nil {
+		return userError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    user,
+	})
+}
+
+// ListAddresses returns the user's saved addresses
+func (h *UserHandler) ListAddresses(c *fiber.Ctx) error {
+	addresses, err := h.userService.ListAddresses(c.Context(), currentUserID(c))
+	if err != nil {
+		return userError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    addresses,
+	})
+}
+
+// AddAddress saves a new address
+func (h *UserHandler) AddAddress(c *fiber.Ctx) error {
+	var input services.AddressInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	address, err := h.userService.AddAddress(c.Context(), currentUserID(c), input)
+	if err != nil {
+		return userError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    address,
+	})
+}
+
+// UpdateAddress replaces a saved address
+func (h *UserHandler) UpdateAddress(c *fiber.Ctx) error {
+	var input services.AddressInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	address, err := h.userService.UpdateAddress(c.Context(), currentUserID(c), c.Params("id"), input)
+	if err != nil {
+		return userError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    address,
+	})
+}
+
+// DeleteAddress removes a saved address
+func (h *UserHandler) DeleteAddress(c *fiber.Ctx) error {
+	if err := h.userService.DeleteAddress(c.Context(), currentUserID(c), c.Params("id")); err != nil {
+		return userError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Address deleted",
+	})
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+// ChangePassword sets a new password and signs out every other session
+func (h *UserHandler) ChangePassword(c *fiber.Ctx) error {
+	var req ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	userID, err := primitive.ObjectIDFromHex(currentUserID(c))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid User ID"})
+	}
+
+	if err := h.authService.ChangePassword(c.Context(), userID, req.CurrentPassword, req.NewPassword, currentSessionID(c)); err != nil {
+		return userError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Password updated. Other devices have been signed out.",
+	})
+}
+
+type ChangeEmailRequest struct {
+	NewEmail        string `json:"newEmail"`
+	CurrentPassword string `json:"currentPassword"`
+}
+
+// ChangeEmail sends a verification code to the new address
+func (h *UserHandler) ChangeEmail(c *fiber.Ctx) error {
+	var req ChangeEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	userID, err := primitive.ObjectIDFromHex(currentUserID(c))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid User ID"})
+	}
+
+	if err := h.authService.RequestEmailChange(c.Context(), userID, req.NewEmail, req.CurrentPassword); err != nil {
+		return userError(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success": true,
+		"message": "We sent a code to your new email. Enter it to confirm the change.",
+	})
+}
+
+type VerifyEmailChangeRequest struct {
+	Code string `json:"code"`
+}
+
+// VerifyEmailChange confirms the code, switches the account email and signs
+// out every other session
+func (h *UserHandler) VerifyEmailChange(c *fiber.Ctx) error {
+	var req VerifyEmailChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	userID, err := primitive.ObjectIDFromHex(currentUserID(c))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid User ID"})
+	}
+
+	user, err := h.authService.ConfirmEmailChange(c.Context(), userID, req.Code, currentSessionID(c), clientInfo(c))
+	if err != nil {
+		return userError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    user,
+		"message": "Email updated. Other devices have been signed out.",
+	})
+}
+
+func currentUserID(c *fiber.Ctx) string {
+	userToken := c.Locals("user").(*jwt.Token)
+	claims := userToken.Claims.(jwt.MapClaims)
+	return claims["userId"].(string)
+}
+
+func currentSessionID(c *fiber.Ctx) string {
+	userToken := c.Locals("user").(*jwt.Token)
+	claims := userToken.Claims.(jwt.MapClaims)
+	sid, _ := claims["sid"].(string)
+	return sid
+}
+
+func userError(c *fiber.Ctx, err error) error {
+	var validation *services.ValidationError
+	if errors.As(err, &validation) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": validation.Error(), "fields": validation.Fields})
+	}
+
+	var rateLimit *services.RateLimitError
+	if errors.As(err, &rateLimit) {
+		return authError(c, err)
+	}
+
+	switch {
+	case errors.Is(err, services.ErrWeakPassword),
+		errors.Is(err, services.ErrIncorrectPassword),
+		errors.Is(err, services.ErrInvalidEmail),
+		errors.Is(err, services.ErrInvalidOTP),
+		errors.Is(err, services.ErrNoPendingEmail):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrEmailTaken):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if errors.Is(err, services.ErrAddressNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Address not found"})
+	}
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
@@ -1,12 +1,16 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/golang-jwt/jwt/v5"
@@ -27,12 +31,60 @@ func SetupMiddleware(app *fiber.App, cfg *config.Config) {
 	origins := strings.Split(cfg.CORS.AllowedOrigins, ",")
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     strings.Join(origins, ", "),
-		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Session-ID",
+		AllowMethods:     "GET,POST,PUT,PATCH,DELETE,OPTIONS",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Session-ID, If-None-Match, If-Modified-Since",
+		ExposeHeaders:    "ETag, Last-Modified",
 		AllowCredentials: true,
 	}))
 }
 
+// TokenValidator performs server-side checks on a verified token, such as
+// whether its session has been revoked
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, claims jwt.MapClaims) error
+}
+
+var tokenValidator TokenValidator
+
+// SetTokenValidator installs the validator used by Protected and ParseToken
+func SetTokenValidator(v TokenValidator) {
+	tokenValidator = v
+}
+
+func jwtSecret() []byte {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		secret = "change-this-secret"
+	}
+	return []byte(secret)
+}
+
+// ParseToken verifies the bearer token in an Authorization header value and
+// runs the installed TokenValidator against its claims
+func ParseToken(ctx context.Context, authHeader string) (*jwt.Token, error) {
+	tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return jwtSecret(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	if tokenValidator != nil {
+		if err := tokenValidator.ValidateToken(ctx, token.Claims.(jwt.MapClaims)); err != nil {
+			return nil, err
+		}
+	}
+
+	return token, nil
+}
+
 // Protected middleware verifies JWT token
 func Protected() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -41,15 +93,8 @@ func Protected() fiber.Handler {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Missing Authorization Header"})
 		}
 
-		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
-
-		if err != nil || !token.Valid {
+		token, err := ParseToken(c.Context(), authHeader)
+		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid or Expired Token"})
 		}
 
@@ -59,3 +104,55 @@ func Protected() fiber.Handler {
 		return c.Next()
 	}
 }
+
+// RequireRole allows requests whose token carries at least one of the given
+// roles. It must run after Protected.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token, ok := c.Locals("user").(*jwt.Token)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		}
+		claims := token.Claims.(jwt.MapClaims)
+		granted, _ := claims["roles"].([]interface{})
+
+		for _, g := range granted {
+			for _, role := range roles {
+				if g == role {
+					return c.Next()
+				}
+			}
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+}
+
+// CacheControl sets the Cache-Control header on successful responses, leaving
+// errors uncacheable. An empty policy sends no header.
+func CacheControl(policy string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if err != nil || policy == "" {
+			return err
+		}
+
+		switch c.Response().StatusCode() {
+		case fiber.StatusOK, fiber.StatusNotModified:
+			c.Set(fiber.HeaderCacheControl, policy)
+		}
+		return nil
+	}
+}
+
+// RateLimit allows each client IP max requests per window, answering the
+// rest with 429 and a Retry-After header. Counts are kept per instance.
+func RateLimit(max int, window time.Duration) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        max,
+		Expiration: window,
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "Too many requests, please try again later"})
+		},
+	})
+}
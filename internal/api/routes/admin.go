@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/khusa-mahal/backend/internal/api/middleware"
+	"github.com/khusa-mahal/backend/internal/models"
+)
+
+// AdminGroup returns the /admin router. Every route registered on it requires
+// a valid token carrying the admin role.
+func AdminGroup(router fiber.Router) fiber.Router {
+	return router.Group("/admin", middleware.Protected(), middleware.RequireRole(models.RoleAdmin))
+}
@@ -9,5 +9,10 @@ func RegisterAuthRoutes(router fiber.Router, handler *handlers.AuthHandler) {
 	auth := router.Group("/auth")
 	auth.Post("/register", handler.Register)
 	auth.Post("/verify-otp", handler.VerifyOTP)
+	auth.Post("/resend-otp", handler.ResendOTP)
 	auth.Post("/login", handler.Login)
+	auth.Post("/refresh", handler.Refresh)
+	auth.Post("/logout", OptionalAuth(), handler.Logout)
+	auth.Post("/forgot-password", handler.ForgotPassword)
+	auth.Post("/reset-password", handler.ResetPassword)
 }
@@ -1,12 +1,7 @@
 package routes
 
 import (
-	"fmt"
-	"os"
-	"strings"
-
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/khusa-mahal/backend/internal/api/handlers"
 	"github.com/khusa-mahal/backend/internal/api/middleware"
 )
@@ -16,24 +11,8 @@ func OptionalAuth() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader != "" {
-			tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-			token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-				}
-				secret := os.Getenv("JWT_SECRET")
-				if secret == "" {
-					secret = "change-this-secret"
-				}
-				return []byte(secret), nil
-			})
-
-			if err != nil {
-				// Log error for debugging if needed, but OptionalAuth should just fail to Guest
-				// fmt.Println("OptionalAuth Error:", err)
-			}
-
-			if token != nil && token.Valid {
+			// Invalid, expired or revoked tokens just fall back to Guest
+			if token, err := middleware.ParseToken(c.Context(), authHeader); err == nil {
 				c.Locals("user", token)
 			}
 		}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/khusa-mahal/backend/internal/api/handlers"
+	"github.com/khusa-mahal/backend/internal/api/middleware"
+)
+
+func RegisterCategoryRoutes(router fiber.Router, handler *handlers.CategoryHandler, cacheControl string) {
+	categories := router.Group("/categories")
+
+	categories.Get("/", middleware.CacheControl(cacheControl), handler.GetCategories)
+	categories.Get("/:slug/products", middleware.CacheControl(cacheControl), handler.GetCategoryProducts)
+}
+
+// RegisterAdminCategoryRoutes registers category management routes on an admin-only router
+func RegisterAdminCategoryRoutes(admin fiber.Router, handler *handlers.CategoryHandler) {
+	categories := admin.Group("/categories")
+
+	categories.Get("/", handler.GetCategories)
+	categories.Post("/", handler.CreateCategory)
+	categories.Put("/:id", handler.UpdateCategory)
+	categories.Delete("/:id", handler.DeleteCategory)
+}
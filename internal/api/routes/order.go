@@ -13,4 +13,16 @@ func RegisterOrderRoutes(router fiber.Router, handler *handlers.OrderHandler) {
 
 	orders.Get("/", handler.GetOrders)
 	orders.Post("/", handler.CreateOrder)
+	orders.Post("/quote", handler.QuoteOrder)
+	orders.Get("/:id", handler.GetOrder)
+	orders.Post("/:id/cancel", handler.CancelOrder)
+}
+
+// RegisterAdminOrderRoutes registers order management routes on an admin-only router
+func RegisterAdminOrderRoutes(admin fiber.Router, handler *handlers.OrderHandler) {
+	orders := admin.Group("/orders")
+
+	orders.Get("/", handler.AdminListOrders)
+	orders.Get("/:id", handler.AdminGetOrder)
+	orders.Put("/:id/status", handler.AdminUpdateStatus)
 }
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/khusa-mahal/backend/internal/api/handlers"
+	"github.com/khusa-mahal/backend/internal/api/middleware"
+)
+
+func RegisterReviewRoutes(router fiber.Router, handler *handlers.ReviewHandler) {
+	reviews := router.Group("/products/:id/reviews")
+
+	reviews.Get("/", handler.GetReviews)
+	reviews.Post("/", middleware.Protected(), handler.CreateReview)
+}
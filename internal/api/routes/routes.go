@@ -1,30 +1,37 @@
 package routes
 
 import (
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/khusa-mahal/backend/internal/api/handlers"
+	"github.com/khusa-mahal/backend/internal/api/middleware"
+	"github.com/khusa-mahal/backend/internal/config"
 )
 
 // SetupRoutes configures all API routes
-func SetupRoutes(app *fiber.App, productHandler *handlers.ProductHandler) {
+func SetupRoutes(app *fiber.App, productHandler *handlers.ProductHandler, healthHandler *handlers.HealthHandler, httpCache config.HTTPCacheConfig) {
 	api := app.Group("/api/v1")
 
 	// Health check
-	api.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status": "healthy",
-		})
-	})
+	api.Get("/health", healthHandler.Health)
 
 	// Product routes
 	products := api.Group("/products")
-	products.Get("/", productHandler.GetProducts)
-	products.Get("/search", productHandler.SearchProducts)
-	products.Get("/:id", productHandler.GetProduct)
+	products.Get("/", middleware.CacheControl(httpCache.Products), productHandler.GetProducts)
+	products.Get("/search", OptionalAuth(), productHandler.SearchProducts)
+	products.Post("/search/click", middleware.RateLimit(60, time.Minute), productHandler.RecordSearchClick)
+	products.Get("/suggest", middleware.CacheControl(httpCache.Suggest), productHandler.SuggestProducts)
+	products.Get("/:id", middleware.CacheControl(httpCache.Product), productHandler.GetProduct)
+}
 
-	// TODO: Add more routes
-	// - Cart routes
-	// - Order routes
-	// - Auth routes
-	// - Category routes
+// RegisterAdminProductRoutes registers catalogue management routes on an admin-only router
+func RegisterAdminProductRoutes(admin fiber.Router, productHandler *handlers.ProductHandler) {
+	products := admin.Group("/products")
+	products.Get("/", productHandler.AdminListProducts)
+	products.Post("/", productHandler.CreateProduct)
+	products.Put("/:id", productHandler.UpdateProduct)
+	products.Post("/:id/archive", productHandler.ArchiveProduct)
+	products.Post("/:id/restore", productHandler.RestoreProduct)
+	products.Delete("/:id", productHandler.DeleteProduct)
 }
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/khusa-mahal/backend/internal/api/handlers"
+)
+
+// RegisterAdminSearchRoutes registers search tuning routes on an admin-only router
+func RegisterAdminSearchRoutes(admin fiber.Router, handler *handlers.SearchAdminHandler) {
+	search := admin.Group("/search")
+
+	search.Get("/synonyms", handler.GetSynonyms)
+	search.Post("/synonyms", handler.CreateSynonyms)
+	search.Post("/synonyms/reload", handler.ReloadSynonyms)
+	search.Put("/synonyms/:id", handler.UpdateSynonyms)
+	search.Delete("/synonyms/:id", handler.DeleteSynonyms)
+
+	search.Get("/analytics", handler.GetAnalytics)
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/khusa-mahal/backend/internal/api/handlers"
+	"github.com/khusa-mahal/backend/internal/api/middleware"
+)
+
+// RegisterUserRoutes registers the signed-in user's profile and address book routes
+func RegisterUserRoutes(router fiber.Router, handler *handlers.UserHandler) {
+	me := router.Group("/me")
+	me.Use(middleware.Protected())
+
+	me.Get("/", handler.GetProfile)
+	me.Patch("/", handler.UpdateProfile)
+	me.Post("/password", handler.ChangePassword)
+	me.Post("/email", handler.ChangeEmail)
+	me.Post("/email/verify", handler.VerifyEmailChange)
+
+	me.Get("/addresses", handler.ListAddresses)
+	me.Post("/addresses", handler.AddAddress)
+	me.Put("/addresses/:id", handler.UpdateAddress)
+	me.Delete("/addresses/:id", handler.DeleteAddress)
+}
@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Breaker wraps a remote store with a circuit breaker. After Threshold
+// consecutive failures the store is skipped, with calls failing at once with
+// ErrUnavailable; every Cooldown a single call is let through to probe it,
+// and the first success closes the breaker again.
+type Breaker struct {
+	store     Store
+	name      string
+	threshold int
+	cooldown  time.Duration
+	onRecover func()
+
+	mu         sync.Mutex
+	failures   int
+	retryAfter time.Time // While open, when the next probe may run
+}
+
+func NewBreaker(name string, store Store, threshold int, cooldown time.Duration) *Breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &Breaker{
+		store:     store,
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// OnRecover registers fn to run, in its own goroutine, each time the breaker
+// closes again. Writes skipped while it was open are lost, so callers use it
+// to repair what depended on them.
+func (b *Breaker) OnRecover(fn func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onRecover = fn
+}
+
+func (b *Breaker) Get(ctx context.Context, key string) ([]byte, error) {
+	if !b.allow() {
+		return nil, ErrUnavailable
+	}
+	value, err := b.store.Get(ctx, key)
+	b.record(err)
+	return value, err
+}
+
+func (b *Breaker) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return b.call(func() error { return b.store.Set(ctx, key, value, ttl) })
+}
+
+func (b *Breaker) Delete(ctx context.Context, keys ...string) error {
+	return b.call(func() error { return b.store.Delete(ctx, keys...) })
+}
+
+func (b *Breaker) Incr(ctx context.Context, key string) (int64, error) {
+	var value int64
+	err := b.call(func() (err error) {
+		value, err = b.store.Incr(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+func (b *Breaker) Counters(ctx context.Context, keys ...string) ([]int64, error) {
+	var values []int64
+	err := b.call(func() (err error) {
+		values, err = b.store.Counters(ctx, keys...)
+		return err
+	})
+	return values, err
+}
+
+// Ping always reaches the store, so it can be used to check it directly
+func (b *Breaker) Ping(ctx context.Context) error {
+	err := b.store.Ping(ctx)
+	b.record(err)
+	return err
+}
+
+// Open reports whether the store is currently being skipped
+func (b *Breaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.threshold
+}
+
+func (b *Breaker) call(fn func() error) error {
+	if !b.allow() {
+		return ErrUnavailable
+	}
+	err := fn()
+	b.record(err)
+	return err
+}
+
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if time.Now().Before(b.retryAfter) {
+		return false
+	}
+	// Let this call probe the store; the rest keep skipping it until it
+	// reports back or the next cooldown passes
+	b.retryAfter = time.Now().Add(b.cooldown)
+	return true
+}
+
+func (b *Breaker) record(err error) {
+	// A caller giving up says nothing about the store's health
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil || errors.Is(err, ErrMiss) {
+		if b.failures >= b.threshold {
+			fmt.Printf("✅ %s is reachable again\n", b.name)
+			if b.onRecover != nil {
+				go b.onRecover()
+			}
+		}
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures == b.threshold {
+		b.retryAfter = time.Now().Add(b.cooldown)
+		fmt.Printf("⚠️  %s unavailable, skipping it for %s: %v\n", b.name, b.cooldown, err)
+	}
+}
@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errDown = errors.New("store down")
+
+// flakyStore is an LRU that fails every call while down, counting the calls
+// that reached it
+type flakyStore struct {
+	*LRU
+
+	mu    sync.Mutex
+	down  bool
+	calls int
+}
+
+func newFlakyStore() *flakyStore {
+	return &flakyStore{LRU: NewLRU(100)}
+}
+
+func (s *flakyStore) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+func (s *flakyStore) callCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func (s *flakyStore) check() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.down {
+		return errDown
+	}
+	return nil
+}
+
+func (s *flakyStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	return s.LRU.Get(ctx, key)
+}
+
+func (s *flakyStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+	return s.LRU.Set(ctx, key, value, ttl)
+}
+
+func (s *flakyStore) Delete(ctx context.Context, keys ...string) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+	return s.LRU.Delete(ctx, keys...)
+}
+
+func (s *flakyStore) Incr(ctx context.Context, key string) (int64, error) {
+	if err := s.check(); err != nil {
+		return 0, err
+	}
+	return s.LRU.Incr(ctx, key)
+}
+
+func (s *flakyStore) Counters(ctx context.Context, keys ...string) ([]int64, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	return s.LRU.Counters(ctx, keys...)
+}
+
+func (s *flakyStore) Ping(ctx context.Context) error {
+	return s.check()
+}
+
+func TestBreakerTripsAfterThreshold(t *testing.T) {
+	ctx := context.Background()
+	store := newFlakyStore()
+	breaker := NewBreaker("test", store, 3, time.Hour)
+	store.setDown(true)
+
+	for i := 0; i < 3; i++ {
+		if _, err := breaker.Get(ctx, "key"); !errors.Is(err, errDown) {
+			t.Fatalf("call %d: error = %v, want the store's error", i, err)
+		}
+		if open := breaker.Open(); open != (i == 2) {
+			t.Fatalf("after %d failures Open() = %v", i+1, open)
+		}
+	}
+
+	// Open: calls fail at once without reaching the store
+	calls := store.callCount()
+	if err := breaker.Set(ctx, "key", []byte("x"), 0); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Set error = %v, want ErrUnavailable", err)
+	}
+	if _, err := breaker.Incr(ctx, "gen"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Incr error = %v, want ErrUnavailable", err)
+	}
+	if store.callCount() != calls {
+		t.Errorf("open breaker reached the store %d times", store.callCount()-calls)
+	}
+}
+
+func TestBreakerIgnoresMissesAndCancellation(t *testing.T) {
+	ctx := context.Background()
+	breaker := NewBreaker("test", newFlakyStore(), 1, time.Hour)
+
+	if _, err := breaker.Get(ctx, "missing"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get error = %v, want ErrMiss", err)
+	}
+	breaker.record(context.Canceled)
+	if breaker.Open() {
+		t.Error("breaker opened on a miss or a cancelled call")
+	}
+}
+
+func TestBreakerProbesAndCloses(t *testing.T) {
+	ctx := context.Background()
+	store := newFlakyStore()
+	cooldown := 20 * time.Millisecond
+	breaker := NewBreaker("test", store, 1, cooldown)
+
+	recovered := make(chan struct{}, 1)
+	breaker.OnRecover(func() { recovered <- struct{}{} })
+
+	store.setDown(true)
+	_, _ = breaker.Get(ctx, "key")
+	if !breaker.Open() {
+		t.Fatal("breaker did not open")
+	}
+
+	// A failed probe keeps it open for another cooldown
+	time.Sleep(cooldown + 5*time.Millisecond)
+	if _, err := breaker.Get(ctx, "key"); !errors.Is(err, errDown) {
+		t.Fatalf("probe error = %v, want the store's error", err)
+	}
+	if _, err := breaker.Get(ctx, "key"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("call after failed probe error = %v, want ErrUnavailable", err)
+	}
+
+	// A successful probe closes it
+	store.setDown(false)
+	time.Sleep(cooldown + 5*time.Millisecond)
+	if _, err := breaker.Incr(ctx, "gen"); err != nil {
+		t.Fatalf("probe error = %v", err)
+	}
+	if breaker.Open() {
+		t.Fatal("breaker still open after a successful probe")
+	}
+
+	select {
+	case <-recovered:
+	case <-time.After(time.Second):
+		t.Fatal("OnRecover handler did not run")
+	}
+}
+
+func TestBreakerLetsOneProbeThrough(t *testing.T) {
+	ctx := context.Background()
+	store := newFlakyStore()
+	cooldown := 20 * time.Millisecond
+	breaker := NewBreaker("test", store, 1, cooldown)
+
+	store.setDown(true)
+	_, _ = breaker.Get(ctx, "key")
+	time.Sleep(cooldown + 5*time.Millisecond)
+
+	calls := store.callCount()
+	for i := 0; i < 5; i++ {
+		_, _ = breaker.Get(ctx, "key")
+	}
+	if got := store.callCount() - calls; got != 1 {
+		t.Errorf("%d calls reached the store after the cooldown, want 1", got)
+	}
+}
@@ -0,0 +1,40 @@
+// Package cache defines the byte-level key-value stores behind the Redis
+// cache: Redis itself, a bounded in-process LRU, a two-tier combination of
+// the two, and a circuit breaker that stops calling Redis while it is down.
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Cache modes accepted in CACHE_MODE
+const (
+	ModeRedis  = "redis"
+	ModeMemory = "memory"
+	ModeTiered = "tiered"
+)
+
+// ErrMiss is returned by Get when the key isn't stored
+var ErrMiss = errors.New("cache miss")
+
+// ErrUnavailable is returned while a store is skipped by its circuit breaker
+var ErrUnavailable = errors.New("cache unavailable")
+
+// Store keeps cached values by key
+type Store interface {
+	// Get returns the value stored under key, or ErrMiss
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value under key for ttl; a zero ttl never expires
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the given keys
+	Delete(ctx context.Context, keys ...string) error
+	// Incr increments the counter under key, starting from zero, and returns
+	// its new value. Counters never expire.
+	Incr(ctx context.Context, key string) (int64, error)
+	// Counters returns the values of the given counters, zero for missing ones
+	Counters(ctx context.Context, keys ...string) ([]int64, error)
+	// Ping checks the store can be reached
+	Ping(ctx context.Context) error
+}
@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU is an in-process Store holding at most a fixed number of entries,
+// dropping the least recently used one when full. Counters are kept apart and
+// never dropped, so a generation can't fall back to a value whose entries are
+// still cached.
+type LRU struct {
+	mu       sync.Mutex
+	max      int
+	order    *list.List // Front is the most recently used
+	entries  map[string]*list.Element
+	counters map[string]int64
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time // Zero never expires
+}
+
+func NewLRU(maxEntries int) *LRU {
+	if maxEntries < 1 {
+		maxEntries = 1
+	}
+	return &LRU{
+		max:      maxEntries,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		counters: make(map[string]int64),
+	}
+}
+
+func (l *LRU) Get(ctx context.Context, key string) ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.entries[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		l.remove(elem)
+		return nil, ErrMiss
+	}
+
+	l.order.MoveToFront(elem)
+	return entry.value, nil
+}
+
+func (l *LRU) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.entries[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		l.order.MoveToFront(elem)
+		return nil
+	}
+
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	for l.order.Len() > l.max {
+		l.remove(l.order.Back())
+	}
+	return nil
+}
+
+func (l *LRU) Delete(ctx context.Context, keys ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := l.entries[key]; ok {
+			l.remove(elem)
+		}
+	}
+	return nil
+}
+
+func (l *LRU) Incr(ctx context.Context, key string) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.counters[key]++
+	return l.counters[key], nil
+}
+
+func (l *LRU) Counters(ctx context.Context, keys ...string) ([]int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	values := make([]int64, len(keys))
+	for i, key := range keys {
+		values[i] = l.counters[key]
+	}
+	return values, nil
+}
+
+func (l *LRU) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Len returns the number of entries held, expired ones included
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+func (l *LRU) remove(elem *list.Element) {
+	l.order.Remove(elem)
+	delete(l.entries, elem.Value.(*lruEntry).key)
+}
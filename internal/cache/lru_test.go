@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(2)
+
+	_ = lru.Set(ctx, "a", []byte("1"), 0)
+	_ = lru.Set(ctx, "b", []byte("2"), 0)
+	// Reading a makes b the least recently used
+	if _, err := lru.Get(ctx, "a"); err != nil {
+		t.Fatalf("Get(a) = %v", err)
+	}
+	_ = lru.Set(ctx, "c", []byte("3"), 0)
+
+	tests := []struct {
+		key  string
+		want error
+	}{
+		{"a", nil},
+		{"b", ErrMiss},
+		{"c", nil},
+	}
+	for _, tt := range tests {
+		if _, err := lru.Get(ctx, tt.key); !errors.Is(err, tt.want) {
+			t.Errorf("Get(%s) error = %v, want %v", tt.key, err, tt.want)
+		}
+	}
+	if lru.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", lru.Len())
+	}
+}
+
+func TestLRUOverwriteKeepsSize(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(2)
+
+	_ = lru.Set(ctx, "a", []byte("1"), 0)
+	_ = lru.Set(ctx, "a", []byte("2"), 0)
+
+	value, err := lru.Get(ctx, "a")
+	if err != nil || string(value) != "2" {
+		t.Fatalf("Get(a) = %q, %v; want \"2\"", value, err)
+	}
+	if lru.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", lru.Len())
+	}
+}
+
+func TestLRUExpiry(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(10)
+
+	_ = lru.Set(ctx, "short", []byte("1"), 10*time.Millisecond)
+	_ = lru.Set(ctx, "long", []byte("2"), time.Hour)
+	_ = lru.Set(ctx, "forever", []byte("3"), 0)
+	time.Sleep(20 * time.Millisecond)
+
+	tests := []struct {
+		key  string
+		want error
+	}{
+		{"short", ErrMiss},
+		{"long", nil},
+		{"forever", nil},
+	}
+	for _, tt := range tests {
+		if _, err := lru.Get(ctx, tt.key); !errors.Is(err, tt.want) {
+			t.Errorf("Get(%s) error = %v, want %v", tt.key, err, tt.want)
+		}
+	}
+	// The expired entry is dropped when read
+	if lru.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", lru.Len())
+	}
+}
+
+func TestLRUDelete(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(10)
+
+	_ = lru.Set(ctx, "a", []byte("1"), 0)
+	_ = lru.Set(ctx, "b", []byte("2"), 0)
+	_ = lru.Delete(ctx, "a", "missing")
+
+	if _, err := lru.Get(ctx, "a"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get(a) error = %v, want ErrMiss", err)
+	}
+	if _, err := lru.Get(ctx, "b"); err != nil {
+		t.Errorf("Get(b) error = %v", err)
+	}
+}
+
+func TestLRUCountersSurviveEviction(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(1)
+
+	for i := 0; i < 3; i++ {
+		if _, err := lru.Incr(ctx, "gen"); err != nil {
+			t.Fatalf("Incr = %v", err)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		_ = lru.Set(ctx, fmt.Sprintf("key%d", i), []byte("x"), 0)
+	}
+
+	values, err := lru.Counters(ctx, "gen", "unknown")
+	if err != nil {
+		t.Fatalf("Counters = %v", err)
+	}
+	if values[0] != 3 || values[1] != 0 {
+		t.Errorf("Counters = %v, want [3 0]", values)
+	}
+}
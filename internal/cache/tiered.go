@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Tiered puts a small, short-lived local store (L1) in front of a shared one
+// (L2). Reads are served from L1 when possible and copied into it from L2;
+// writes and deletes go to both. L1 keeps serving what it holds while L2 is
+// unreachable. Counters are shared state, so they are only kept in L1 while
+// L2 can't be reached, and bumping one there still reports L2's error: the
+// other instances never see it.
+type Tiered struct {
+	L1 Store
+	L2 Store
+	// L1TTL caps how long an entry lives in L1, bounding how stale a copy can
+	// get when an invalidation from another instance is missed
+	L1TTL time.Duration
+}
+
+func (t *Tiered) Get(ctx context.Context, key string) ([]byte, error) {
+	if value, err := t.L1.Get(ctx, key); err == nil {
+		return value, nil
+	}
+
+	value, err := t.L2.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	_ = t.L1.Set(ctx, key, value, t.L1TTL)
+	return value, nil
+}
+
+func (t *Tiered) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_ = t.L1.Set(ctx, key, value, t.localTTL(ttl))
+	return t.L2.Set(ctx, key, value, ttl)
+}
+
+func (t *Tiered) Delete(ctx context.Context, keys ...string) error {
+	_ = t.L1.Delete(ctx, keys...)
+	return t.L2.Delete(ctx, keys...)
+}
+
+func (t *Tiered) Incr(ctx context.Context, key string) (int64, error) {
+	value, err := t.L2.Incr(ctx, key)
+	if err == nil {
+		return value, nil
+	}
+	if value, localErr := t.L1.Incr(ctx, key); localErr == nil {
+		return value, err
+	}
+	return 0, err
+}
+
+func (t *Tiered) Counters(ctx context.Context, keys ...string) ([]int64, error) {
+	if values, err := t.L2.Counters(ctx, keys...); err == nil {
+		return values, nil
+	}
+	return t.L1.Counters(ctx, keys...)
+}
+
+func (t *Tiered) Ping(ctx context.Context) error {
+	return t.L2.Ping(ctx)
+}
+
+func (t *Tiered) localTTL(ttl time.Duration) time.Duration {
+	if ttl == 0 || ttl > t.L1TTL {
+		return t.L1TTL
+	}
+	return ttl
+}
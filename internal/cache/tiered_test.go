@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTiered() (*Tiered, *LRU, *flakyStore) {
+	l1 := NewLRU(100)
+	l2 := newFlakyStore()
+	return &Tiered{L1: l1, L2: l2, L1TTL: time.Minute}, l1, l2
+}
+
+func TestTieredGetFillsL1(t *testing.T) {
+	ctx := context.Background()
+	tiered, l1, l2 := newTiered()
+
+	_ = l2.Set(ctx, "key", []byte("value"), 0)
+	if value, err := tiered.Get(ctx, "key"); err != nil || string(value) != "value" {
+		t.Fatalf("Get = %q, %v", value, err)
+	}
+	if value, err := l1.Get(ctx, "key"); err != nil || string(value) != "value" {
+		t.Errorf("L1 Get = %q, %v; want the value copied from L2", value, err)
+	}
+}
+
+func TestTieredServesL1WhileL2IsDown(t *testing.T) {
+	ctx := context.Background()
+	tiered, _, l2 := newTiered()
+
+	if err := tiered.Set(ctx, "key", []byte("value"), time.Hour); err != nil {
+		t.Fatalf("Set = %v", err)
+	}
+	l2.setDown(true)
+
+	tests := []struct {
+		key     string
+		want    string
+		wantErr error
+	}{
+		{"key", "value", nil},
+		{"missing", "", errDown},
+	}
+	for _, tt := range tests {
+		value, err := tiered.Get(ctx, tt.key)
+		if string(value) != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("Get(%s) = %q, %v; want %q, %v", tt.key, value, err, tt.want, tt.wantErr)
+		}
+	}
+
+	if err := tiered.Set(ctx, "other", []byte("x"), 0); !errors.Is(err, errDown) {
+		t.Errorf("Set error = %v, want L2's error", err)
+	}
+}
+
+func TestTieredCapsL1TTL(t *testing.T) {
+	tiered := &Tiered{L1TTL: time.Minute}
+
+	tests := []struct {
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{0, time.Minute},
+		{time.Hour, time.Minute},
+		{time.Second, time.Second},
+	}
+	for _, tt := range tests {
+		if got := tiered.localTTL(tt.ttl); got != tt.want {
+			t.Errorf("localTTL(%s) = %s, want %s", tt.ttl, got, tt.want)
+		}
+	}
+}
+
+func TestTieredCountersFallBackToL1(t *testing.T) {
+	ctx := context.Background()
+	tiered, _, l2 := newTiered()
+
+	if value, err := tiered.Incr(ctx, "gen"); err != nil || value != 1 {
+		t.Fatalf("Incr = %d, %v; want 1 from L2", value, err)
+	}
+
+	l2.setDown(true)
+	// The L1 bump is returned along with L2's error, since it isn't shared
+	if value, err := tiered.Incr(ctx, "gen"); !errors.Is(err, errDown) || value != 1 {
+		t.Errorf("Incr = %d, %v; want 1 from L1 and L2's error", value, err)
+	}
+	if values, err := tiered.Counters(ctx, "gen"); err != nil || values[0] != 1 {
+		t.Errorf("Counters = %v, %v; want [1] from L1", values, err)
+	}
+
+	l2.setDown(false)
+	if values, err := tiered.Counters(ctx, "gen"); err != nil || values[0] != 1 {
+		t.Errorf("Counters = %v, %v; want [1] from L2", values, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -14,9 +15,13 @@ type Config struct {
 	MongoDB       MongoDBConfig
 	Redis         RedisConfig
 	Elasticsearch ElasticsearchConfig
+	Search        SearchConfig
 	JWT           JWTConfig
 	CORS          CORSConfig
 	Cache         CacheConfig
+	HTTPCache     HTTPCacheConfig
+	Pricing       PricingConfig
+	OTP           OTPConfig
 }
 
 type ServerConfig struct {
@@ -37,13 +42,21 @@ type RedisConfig struct {
 }
 
 type ElasticsearchConfig struct {
-	URL   string
-	Index string
+	URL         string
+	Index       string
+	SyncEnabled bool // Tail the products change stream into the index
+}
+
+// SearchConfig picks the product search implementation
+type SearchConfig struct {
+	Backend      string        // elasticsearch, mongo or memory
+	LogRetention time.Duration // How long search analytics are kept
 }
 
 type JWTConfig struct {
-	Secret string
-	Expiry time.Duration
+	Secret        string
+	Expiry        time.Duration // Access token lifetime
+	RefreshExpiry time.Duration // Refresh token (session) lifetime
 }
 
 type CORSConfig struct {
@@ -51,9 +64,40 @@ type CORSConfig struct {
 }
 
 type CacheConfig struct {
-	ProductTTL time.Duration
-	ListTTL    time.Duration
-	CartTTL    time.Duration
+	Mode             string // redis, memory or tiered (in-process L1 in front of Redis)
+	ProductTTL       time.Duration
+	ListTTL          time.Duration
+	CartTTL          time.Duration
+	StaleTTL         time.Duration // How long past its TTL a product or list may be served while it is refreshed
+	NegativeTTL      time.Duration // How long an unknown product ID is remembered
+	LocalMaxEntries  int           // Size of the in-process cache
+	LocalTTL         time.Duration // Longest an entry stays in the in-process cache in tiered mode
+	BreakerThreshold int           // Consecutive Redis failures before Redis is skipped
+	BreakerCooldown  time.Duration // How long Redis is skipped before it is probed again
+}
+
+// HTTPCacheConfig holds the Cache-Control sent with successful catalog
+// responses; an empty value sends none
+type HTTPCacheConfig struct {
+	Products   string // GET /products
+	Product    string // GET /products/:id
+	Categories string // GET /categories and /categories/:slug/products
+	Suggest    string // GET /products/suggest
+}
+
+type PricingConfig struct {
+	ShippingFlatRate      float64
+	FreeShippingThreshold float64
+}
+
+// OTPConfig limits how often one-time codes can be sent and guessed
+type OTPConfig struct {
+	MaxAttempts        int           // Wrong guesses allowed per code
+	ResendCooldown     time.Duration // Minimum gap between codes sent to one email
+	MaxSendsPerHour    int           // Codes sent to one email per hour
+	LockoutThreshold   int           // Failed verifications per email before lockout
+	IPLockoutThreshold int           // Failed verifications per IP before lockout
+	LockoutWindow      time.Duration // How long failures are counted and lockouts last
 }
 
 func Load() (*Config, error) {
@@ -63,8 +107,42 @@ func Load() (*Config, error) {
 		fmt.Println("No .env file found, using environment variables")
 	}
 
-	jwtExpiry, _ := time.ParseDuration(getEnv("JWT_EXPIRY", "24h"))
+	jwtExpiry, err := time.ParseDuration(getEnv("JWT_EXPIRY", "15m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_EXPIRY: %w", err)
+	}
+	refreshExpiry, err := time.ParseDuration(getEnv("JWT_REFRESH_EXPIRY", "720h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_REFRESH_EXPIRY: %w", err)
+	}
+	otpCooldown, err := time.ParseDuration(getEnv("OTP_RESEND_COOLDOWN", "60s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTP_RESEND_COOLDOWN: %w", err)
+	}
+	otpLockoutWindow, err := time.ParseDuration(getEnv("OTP_LOCKOUT_WINDOW", "15m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTP_LOCKOUT_WINDOW: %w", err)
+	}
+	searchLogRetention, err := time.ParseDuration(getEnv("SEARCH_LOG_RETENTION", "8784h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SEARCH_LOG_RETENTION: %w", err)
+	}
+	if searchLogRetention < time.Second {
+		return nil, fmt.Errorf("invalid SEARCH_LOG_RETENTION: must be at least 1s")
+	}
 	redisDB, _ := strconv.Atoi(getEnv("REDIS_DB", "0"))
+	searchBackend := getEnv("SEARCH_BACKEND", "elasticsearch")
+	switch searchBackend {
+	case "elasticsearch", "mongo", "memory":
+	default:
+		return nil, fmt.Errorf("invalid SEARCH_BACKEND %q: use elasticsearch, mongo or memory", searchBackend)
+	}
+	cacheMode := getEnv("CACHE_MODE", "tiered")
+	switch cacheMode {
+	case "redis", "memory", "tiered":
+	default:
+		return nil, fmt.Errorf("invalid CACHE_MODE %q: use redis, memory or tiered", cacheMode)
+	}
 
 	return &Config{
 		Server: ServerConfig{
@@ -82,20 +160,51 @@ func Load() (*Config, error) {
 			DB:       redisDB,
 		},
 		Elasticsearch: ElasticsearchConfig{
-			URL:   getEnv("ELASTICSEARCH_URL", "http://localhost:9200"),
-			Index: getEnv("ELASTICSEARCH_INDEX", "products"),
+			URL:         getEnv("ELASTICSEARCH_URL", "http://localhost:9200"),
+			Index:       getEnv("ELASTICSEARCH_INDEX", "products"),
+			SyncEnabled: parseBool(getEnv("SEARCH_SYNC_ENABLED", "true"), true),
+		},
+		Search: SearchConfig{
+			Backend:      searchBackend,
+			LogRetention: searchLogRetention,
 		},
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", "change-this-secret"),
-			Expiry: jwtExpiry,
+			Secret:        getEnv("JWT_SECRET", "change-this-secret"),
+			Expiry:        jwtExpiry,
+			RefreshExpiry: refreshExpiry,
 		},
 		CORS: CORSConfig{
 			AllowedOrigins: getEnv("ALLOWED_ORIGINS", "http://localhost:3000"),
 		},
 		Cache: CacheConfig{
-			ProductTTL: parseDuration(getEnv("CACHE_PRODUCT_TTL", "3600")),
-			ListTTL:    parseDuration(getEnv("CACHE_LIST_TTL", "900")),
-			CartTTL:    parseDuration(getEnv("CACHE_CART_TTL", "604800")),
+			Mode:             cacheMode,
+			ProductTTL:       parseDuration(getEnv("CACHE_PRODUCT_TTL", "3600")),
+			ListTTL:          parseDuration(getEnv("CACHE_LIST_TTL", "900")),
+			CartTTL:          parseDuration(getEnv("CACHE_CART_TTL", "604800")),
+			StaleTTL:         parseDuration(getEnv("CACHE_STALE_TTL", "300")),
+			NegativeTTL:      parseDuration(getEnv("CACHE_NEGATIVE_TTL", "30")),
+			LocalMaxEntries:  parseInt(getEnv("CACHE_LOCAL_MAX_ENTRIES", "10000"), 10000),
+			LocalTTL:         parseDuration(getEnv("CACHE_LOCAL_TTL", "60")),
+			BreakerThreshold: parseInt(getEnv("CACHE_BREAKER_THRESHOLD", "5"), 5),
+			BreakerCooldown:  parseDuration(getEnv("CACHE_BREAKER_COOLDOWN", "10")),
+		},
+		HTTPCache: HTTPCacheConfig{
+			Products:   getCachePolicy("HTTP_CACHE_PRODUCTS", "public, max-age=60"),
+			Product:    getCachePolicy("HTTP_CACHE_PRODUCT", "public, max-age=60"),
+			Categories: getCachePolicy("HTTP_CACHE_CATEGORIES", "public, max-age=300"),
+			Suggest:    getCachePolicy("HTTP_CACHE_SUGGEST", "public, max-age=60"),
+		},
+		Pricing: PricingConfig{
+			ShippingFlatRate:      parseFloat(getEnv("SHIPPING_FLAT_RATE", "250")),
+			FreeShippingThreshold: parseFloat(getEnv("SHIPPING_FREE_THRESHOLD", "5000")),
+		},
+		OTP: OTPConfig{
+			MaxAttempts:        parseInt(getEnv("OTP_MAX_ATTEMPTS", "5"), 5),
+			ResendCooldown:     otpCooldown,
+			MaxSendsPerHour:    parseInt(getEnv("OTP_MAX_SENDS_PER_HOUR", "5"), 5),
+			LockoutThreshold:   parseInt(getEnv("OTP_LOCKOUT_THRESHOLD", "10"), 10),
+			IPLockoutThreshold: parseInt(getEnv("OTP_IP_LOCKOUT_THRESHOLD", "50"), 50),
+			LockoutWindow:      otpLockoutWindow,
 		},
 	}, nil
 }
@@ -107,6 +216,20 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getCachePolicy reads a Cache-Control value. Unlike getEnv, a variable set
+// to nothing or to "none" disables the header instead of using the default.
+func getCachePolicy(key, defaultValue string) string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "none") {
+		return ""
+	}
+	return value
+}
+
 func parseDuration(seconds string) time.Duration {
 	s, err := strconv.Atoi(seconds)
 	if err != nil {
@@ -114,3 +237,27 @@ func parseDuration(seconds string) time.Duration {
 	}
 	return time.Duration(s) * time.Second
 }
+
+func parseFloat(value string) float64 {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+func parseInt(value string, defaultValue int) int {
+	i, err := strconv.Atoi(value)
+	if err != nil || i <= 0 {
+		return defaultValue
+	}
+	return i
+}
+
+func parseBool(value string, defaultValue bool) bool {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+	return b
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func strPtr(v string) *string {
+	return &v
+}
+
+func TestGetCachePolicy(t *testing.T) {
+	const key = "HTTP_CACHE_TEST"
+	const fallback = "public, max-age=60"
+
+	tests := []struct {
+		name  string
+		value *string // nil leaves the variable unset
+		want  string
+	}{
+		{"unset uses the default", nil, fallback},
+		{"set", strPtr("no-cache"), "no-cache"},
+		{"trimmed", strPtr("  private, max-age=5 "), "private, max-age=5"},
+		{"empty sends none", strPtr(""), ""},
+		{"blank sends none", strPtr("   "), ""},
+		{"none sends none", strPtr("none"), ""},
+		{"none ignores case", strPtr(" None "), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.value == nil {
+				// t.Setenv restores the variable after the test
+				t.Setenv(key, "")
+				os.Unsetenv(key)
+			} else {
+				t.Setenv(key, *tt.value)
+			}
+
+			if got := getCachePolicy(key, fallback); got != tt.want {
+				t.Errorf("getCachePolicy = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
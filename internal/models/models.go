@@ -1,8 +1,11 @@
 package models
 
 import (
+	"strings"
 	"time"
+	"unicode"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -13,11 +16,42 @@ type ColorOption struct {
 	ImageURL string `json:"imageUrl,omitempty" bson:"imageUrl,omitempty"`
 }
 
+// Variant is a purchasable size and color combination with its own stock
+type Variant struct {
+	SKU   string `json:"sku" bson:"sku"`
+	Size  string `json:"size" bson:"size"`
+	Color string `json:"color" bson:"color"`
+	Stock int    `json:"stock" bson:"stock"`
+}
+
+// VariantSKU builds the SKU used for a size and color combination
+func VariantSKU(size, color string) string {
+	return strings.ToUpper(strings.ReplaceAll(size+"-"+color, " ", "_"))
+}
+
+// Slugify turns a name into a lowercase, hyphen-separated URL slug
+func Slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			hyphen = false
+		} else if !hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+			hyphen = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
 // Product represents a khusa product
 type Product struct {
 	ID               primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	Name             string             `json:"name" bson:"name"`
-	Category         string             `json:"category" bson:"category"`
+	NameUrdu         string             `json:"nameUrdu,omitempty" bson:"nameUrdu,omitempty"` // Name in Urdu script, for search
+	Category         string             `json:"category" bson:"category"`                     // Category name, kept in step with CategoryID
+	CategoryID       primitive.ObjectID `json:"categoryId,omitempty" bson:"categoryId,omitempty"`
 	Price            float64            `json:"price" bson:"price"`
 	OriginalPrice    *float64           `json:"originalPrice,omitempty" bson:"originalPrice,omitempty"`
 	Discount         *float64           `json:"discount,omitempty" bson:"discount,omitempty"`
@@ -32,31 +66,111 @@ type Product struct {
 	Reviews          *int               `json:"reviews,omitempty" bson:"reviews,omitempty"`
 	Sizes            []string           `json:"sizes" bson:"sizes"`
 	Colors           []ColorOption      `json:"colors" bson:"colors"`
-	Stock            int                `json:"stock" bson:"stock"`
+	Variants         []Variant          `json:"variants,omitempty" bson:"variants,omitempty"`
+	Stock            int                `json:"stock" bson:"stock"`                             // Total across variants
+	SoldCount        int                `json:"soldCount,omitempty" bson:"soldCount,omitempty"` // Units ordered and not cancelled
+	IsArchived       bool               `json:"isArchived,omitempty" bson:"isArchived,omitempty"`
 	CreatedAt        time.Time          `json:"createdAt" bson:"createdAt"`
 	UpdatedAt        time.Time          `json:"updatedAt" bson:"updatedAt"`
 }
 
+// ProductPage is one page of a product listing
+type ProductPage struct {
+	Products []Product `json:"products"`
+	Total    int64     `json:"total"`
+}
+
+// ProductChange describes a write to one or more products. Categories names
+// the categories whose listings may have changed and CategorySlugs the slugs
+// of their category pages, which admins can set apart from the name. When
+// both are nil, any category may have changed. StockOnly marks a change to
+// stock and sales counts alone, as orders make, which only listings filtered
+// on stock need to see, and only when StockFlipped reports that some stock
+// reached or left zero.
+type ProductChange struct {
+	IDs           []string `json:"ids"`
+	Categories    []string `json:"categories,omitempty"`
+	CategorySlugs []string `json:"categorySlugs,omitempty"`
+	StockOnly     bool     `json:"stockOnly,omitempty"`
+	StockFlipped  bool     `json:"stockFlipped,omitempty"`
+}
+
+// FindVariant returns the variant matching size and color (case-insensitive), or nil
+func (p *Product) FindVariant(size, color string) *Variant {
+	for i := range p.Variants {
+		if strings.EqualFold(p.Variants[i].Size, size) && strings.EqualFold(p.Variants[i].Color, color) {
+			return &p.Variants[i]
+		}
+	}
+	return nil
+}
+
+// User roles
+const (
+	RoleCustomer = "customer"
+	RoleAdmin    = "admin"
+)
+
 // User represents a user account
 type User struct {
 	ID                primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	Email             string             `json:"email" bson:"email"`
+	PendingEmail      string             `json:"pendingEmail,omitempty" bson:"pendingEmail,omitempty"` // Awaiting verification before replacing Email
 	PasswordHash      string             `json:"-" bson:"passwordHash"`
 	Name              string             `json:"name" bson:"name"`
 	Age               int                `json:"age,omitempty" bson:"age,omitempty"`
 	Phone             string             `json:"phone,omitempty" bson:"phone,omitempty"`
-	Address           *Address           `json:"address,omitempty" bson:"address,omitempty"`
+	Address           *Address           `json:"address,omitempty" bson:"address,omitempty"` // Legacy single address, superseded by Addresses
+	Addresses         []SavedAddress     `json:"addresses,omitempty" bson:"addresses,omitempty"`
+	Roles             []string           `json:"roles,omitempty" bson:"roles,omitempty"`
 	IsVerified        bool               `json:"isVerified" bson:"isVerified"`
 	VerificationToken string             `json:"-" bson:"verificationToken,omitempty"`
 	CreatedAt         time.Time          `json:"createdAt" bson:"createdAt"`
 	UpdatedAt         time.Time          `json:"updatedAt" bson:"updatedAt"`
 }
 
-// OTP represents a one-time password for email verification
+// HasRole reports whether the user has been granted role
+func (u *User) HasRole(role string) bool {
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Session is a signed-in device. It holds the hash of the current refresh
+// token plus the hashes of tokens it has already rotated away from, so a
+// replayed old token can be detected.
+type Session struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	UserID         primitive.ObjectID `bson:"userId"`
+	TokenHash      string             `bson:"tokenHash"`
+	PreviousHashes []string           `bson:"previousHashes,omitempty"`
+	UserAgent      string             `bson:"userAgent,omitempty"`
+	IP             string             `bson:"ip,omitempty"`
+	ExpiresAt      time.Time          `bson:"expiresAt"`
+	RevokedAt      *time.Time         `bson:"revokedAt,omitempty"`
+	RevokedReason  string             `bson:"revokedReason,omitempty"`
+	CreatedAt      time.Time          `bson:"createdAt"`
+	LastUsedAt     time.Time          `bson:"lastUsedAt"`
+}
+
+// OTP purposes. A code issued for one purpose can't be used for another.
+const (
+	OTPPurposeSignup        = "signup"
+	OTPPurposePasswordReset = "password_reset"
+	OTPPurposeEmailChange   = "email_change"
+)
+
+// OTP represents a one-time password sent by email. Only an HMAC of the
+// code is stored.
 type OTP struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty"`
 	Email     string             `bson:"email"`
-	Code      string             `bson:"code"`
+	Purpose   string             `bson:"purpose"`
+	CodeHash  string             `bson:"codeHash"`
+	Attempts  int                `bson:"attempts"` // Verification attempts made against this code
 	ExpiresAt time.Time          `bson:"expiresAt"`
 	CreatedAt time.Time          `bson:"createdAt"`
 }
@@ -70,6 +184,36 @@ type Address struct {
 	Country string `json:"country" bson:"country"`
 }
 
+// SavedAddress is an entry in a user's address book
+type SavedAddress struct {
+	ID                primitive.ObjectID `json:"id" bson:"_id"`
+	Label             string             `json:"label" bson:"label"` // e.g. Home, Office
+	Address           `bson:",inline"`
+	IsDefaultShipping bool `json:"isDefaultShipping" bson:"isDefaultShipping"`
+	IsDefaultBilling  bool `json:"isDefaultBilling" bson:"isDefaultBilling"`
+}
+
+// FindAddress returns the saved address with the given ID, or nil
+func (u *User) FindAddress(id primitive.ObjectID) *SavedAddress {
+	for i := range u.Addresses {
+		if u.Addresses[i].ID == id {
+			return &u.Addresses[i]
+		}
+	}
+	return nil
+}
+
+// DefaultShippingAddress returns the address marked as the default for
+// shipping, or nil
+func (u *User) DefaultShippingAddress() *SavedAddress {
+	for i := range u.Addresses {
+		if u.Addresses[i].IsDefaultShipping {
+			return &u.Addresses[i]
+		}
+	}
+	return nil
+}
+
 // CartItem represents an item in the cart
 type CartItem struct {
 	ProductID     primitive.ObjectID `json:"productId" bson:"productId"`
@@ -89,19 +233,52 @@ type Cart struct {
 	UpdatedAt time.Time           `json:"updatedAt" bson:"updatedAt"`
 }
 
+// OrderItem is a single order line with the product details snapshotted at checkout
+type OrderItem struct {
+	ProductID     primitive.ObjectID `json:"productId" bson:"productId"`
+	Name          string             `json:"name" bson:"name"`
+	Image         string             `json:"image" bson:"image"`
+	UnitPrice     float64            `json:"unitPrice" bson:"unitPrice"`
+	Quantity      int                `json:"quantity" bson:"quantity"`
+	SelectedSize  string             `json:"selectedSize" bson:"selectedSize"`
+	SelectedColor string             `json:"selectedColor" bson:"selectedColor"`
+	LineTotal     float64            `json:"lineTotal" bson:"lineTotal"`
+}
+
+// Order statuses. Allowed transitions are enforced by the order service.
+const (
+	OrderStatusPending    = "pending"
+	OrderStatusProcessing = "processing"
+	OrderStatusShipped    = "shipped"
+	OrderStatusDelivered  = "delivered"
+	OrderStatusCancelled  = "cancelled"
+)
+
+// OrderEvent records one status change in an order's timeline
+type OrderEvent struct {
+	Status    string    `json:"status" bson:"status"`
+	From      string    `json:"from,omitempty" bson:"from,omitempty"`
+	Actor     string    `json:"actor" bson:"actor"`         // User ID of whoever made the change, or "system"
+	ActorRole string    `json:"actorRole" bson:"actorRole"` // customer, admin, system
+	Note      string    `json:"note,omitempty" bson:"note,omitempty"`
+	At        time.Time `json:"at" bson:"at"`
+}
+
 // Order represents a completed order
 type Order struct {
 	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	UserID          primitive.ObjectID `json:"userId" bson:"userId"`
-	Items           []CartItem         `json:"items" bson:"items"`
+	Items           []OrderItem        `json:"items" bson:"items"`
 	ShippingAddress Address            `json:"shippingAddress" bson:"shippingAddress"`
 	SubTotal        float64            `json:"subTotal" bson:"subTotal"`
 	ShippingCost    float64            `json:"shippingCost" bson:"shippingCost"`
 	Total           float64            `json:"total" bson:"total"`
 	PaymentMethod   string             `json:"paymentMethod" bson:"paymentMethod"` // cod, card, jazzcash, easypaisa
-	Status          string             `json:"status" bson:"status"`               // pending, processing, shipped, delivered, cancelled
+	Status          string             `json:"status" bson:"status"`               // One of the OrderStatus* constants
 	PaymentStatus   string             `json:"paymentStatus" bson:"paymentStatus"` // pending, completed, failed
+	Timeline        []OrderEvent       `json:"timeline,omitempty" bson:"timeline,omitempty"`
 	SessionID       string             `json:"sessionId,omitempty" bson:"sessionId,omitempty"`
+	StockReserved   bool               `json:"-" bson:"stockReserved,omitempty"` // Orders placed before inventory tracking never decremented stock
 	CreatedAt       time.Time          `json:"createdAt" bson:"createdAt"`
 	UpdatedAt       time.Time          `json:"updatedAt" bson:"updatedAt"`
 }
@@ -117,11 +294,47 @@ type Category struct {
 	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
 }
 
+// SynonymGroup is a set of interchangeable search terms, e.g. Roman-Urdu
+// spellings and the Urdu-script word for the same thing
+type SynonymGroup struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Terms     []string           `json:"terms" bson:"terms"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// SyncState records how far a change stream consumer has got, so it can
+// resume where it stopped
+type SyncState struct {
+	ID          string    `json:"id" bson:"_id"`
+	ResumeToken bson.Raw  `json:"-" bson:"resumeToken,omitempty"`
+	LastEventAt time.Time `json:"lastEventAt" bson:"lastEventAt"`         // Cluster time of the last applied event
+	Owner       string    `json:"owner,omitempty" bson:"owner,omitempty"` // Instance holding the lease
+	LeaseUntil  time.Time `json:"leaseUntil" bson:"leaseUntil,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// SearchLog records one storefront search and what the shopper clicked
+type SearchLog struct {
+	ID               primitive.ObjectID  `json:"id" bson:"_id"`
+	Query            string              `json:"query" bson:"query"` // As typed
+	Term             string              `json:"term" bson:"term"`   // Lowercased, single-spaced; reports group by it
+	Filtered         bool                `json:"filtered" bson:"filtered"`
+	ResultCount      int64               `json:"resultCount" bson:"resultCount"`
+	UserID           *primitive.ObjectID `json:"userId,omitempty" bson:"userId,omitempty"`
+	SessionID        string              `json:"sessionId,omitempty" bson:"sessionId,omitempty"`
+	ClickedProductID *primitive.ObjectID `json:"clickedProductId,omitempty" bson:"clickedProductId,omitempty"` // First click
+	ClickedAt        *time.Time          `json:"clickedAt,omitempty" bson:"clickedAt,omitempty"`
+	Clicks           int                 `json:"clicks" bson:"clicks"`
+	CreatedAt        time.Time           `json:"createdAt" bson:"createdAt"`
+}
+
 // Review represents a product review
 type Review struct {
 	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	ProductID primitive.ObjectID `json:"productId" bson:"productId"`
 	UserID    primitive.ObjectID `json:"userId" bson:"userId"`
+	UserName  string             `json:"userName" bson:"userName"` // Reviewer's name when the review was written
 	Rating    int                `json:"rating" bson:"rating"`
 	Comment   string             `json:"comment,omitempty" bson:"comment,omitempty"`
 	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
@@ -145,3 +358,85 @@ type OrderDetailsItem struct {
 	Size     string
 	Color    string
 }
+
+// SearchQuery is a full-text product search with optional facet filters
+type SearchQuery struct {
+	Text     string   `json:"q"`
+	Category string   `json:"category,omitempty"`
+	MinPrice *float64 `json:"minPrice,omitempty"`
+	MaxPrice *float64 `json:"maxPrice,omitempty"`
+	Sizes    []string `json:"sizes,omitempty"`
+	Colors   []string `json:"colors,omitempty"`
+	IsSale   *bool    `json:"isSale,omitempty"`
+	Page     int      `json:"page"`
+	Limit    int      `json:"limit"`
+}
+
+// FacetBucket is the number of matching products with one facet value
+type FacetBucket struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// PriceRange is a price facet bucket; From is inclusive and To exclusive
+type PriceRange struct {
+	Key   string   `json:"key"`
+	From  *float64 `json:"from,omitempty"`
+	To    *float64 `json:"to,omitempty"`
+	Count int64    `json:"count"`
+}
+
+// SearchFacets holds the counts for each filter. Every facet is counted with
+// all the other filters applied but not its own, so a shopper can see what
+// picking another value would return.
+type SearchFacets struct {
+	Categories []FacetBucket `json:"categories"`
+	Sizes      []FacetBucket `json:"sizes"`
+	Colors     []FacetBucket `json:"colors"`
+	Prices     []PriceRange  `json:"prices"`
+	OnSale     int64         `json:"onSale"`
+}
+
+// SearchResult is one page of search hits with facet counts
+type SearchResult struct {
+	Products   []Product                      `json:"products"`
+	Total      int64                          `json:"total"`
+	Page       int                            `json:"page"`
+	Limit      int                            `json:"limit"`
+	Facets     SearchFacets                   `json:"facets"`
+	Highlights map[string]map[string][]string `json:"highlights,omitempty"` // Product ID → field → fragments
+}
+
+// PriceRanges are the buckets used for the price facet (PKR)
+var PriceRanges = []PriceRange{
+	{Key: "under-2000", To: floatPtr(2000)},
+	{Key: "2000-4000", From: floatPtr(2000), To: floatPtr(4000)},
+	{Key: "4000-6000", From: floatPtr(4000), To: floatPtr(6000)},
+	{Key: "6000-10000", From: floatPtr(6000), To: floatPtr(10000)},
+	{Key: "10000-plus", From: floatPtr(10000)},
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+// Contains reports whether price falls in the range
+func (r PriceRange) Contains(price float64) bool {
+	return (r.From == nil || price >= *r.From) && (r.To == nil || price < *r.To)
+}
+
+// Suggestion types
+const (
+	SuggestionProduct  = "product"
+	SuggestionCategory = "category"
+)
+
+// Suggestion is a lightweight autocomplete entry for the search box
+type Suggestion struct {
+	Type      string  `json:"type"` // product or category
+	Text      string  `json:"text"`
+	ProductID string  `json:"productId,omitempty"`
+	Category  string  `json:"category,omitempty"`
+	Image     string  `json:"image,omitempty"`
+	Price     float64 `json:"price,omitempty"`
+}
@@ -0,0 +1,216 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/khusa-mahal/backend/internal/models"
+)
+
+// Facet names, also used as aggregation names
+const (
+	facetCategories = "categories"
+	facetSizes      = "sizes"
+	facetColors     = "colors"
+	facetPrices     = "prices"
+	facetSale       = "sale"
+)
+
+type termsAggregation struct {
+	Values struct {
+		Buckets []struct {
+			Key      string `json:"key"`
+			DocCount int64  `json:"doc_count"`
+		} `json:"buckets"`
+	} `json:"values"`
+}
+
+func (a termsAggregation) buckets() []models.FacetBucket {
+	buckets := make([]models.FacetBucket, len(a.Values.Buckets))
+	for i, b := range a.Values.Buckets {
+		buckets[i] = models.FacetBucket{Value: b.Key, Count: b.DocCount}
+	}
+	return buckets
+}
+
+// facetFilters returns the filter clause for every facet the query restricts
+func facetFilters(q models.SearchQuery) map[string]map[string]interface{} {
+	filters := map[string]map[string]interface{}{}
+
+	if q.Category != "" {
+		filters[facetCategories] = map[string]interface{}{"term": map[string]interface{}{"category": q.Category}}
+	}
+	if len(q.Sizes) > 0 {
+		filters[facetSizes] = map[string]interface{}{"terms": map[string]interface{}{"sizes": q.Sizes}}
+	}
+	if len(q.Colors) > 0 {
+		filters[facetColors] = map[string]interface{}{"terms": map[string]interface{}{"colors.name": q.Colors}}
+	}
+	if q.MinPrice != nil || q.MaxPrice != nil {
+		price := map[string]interface{}{}
+		if q.MinPrice != nil {
+			price["gte"] = *q.MinPrice
+		}
+		if q.MaxPrice != nil {
+			price["lte"] = *q.MaxPrice
+		}
+		filters[facetPrices] = map[string]interface{}{"range": map[string]interface{}{"price": price}}
+	}
+	if q.IsSale != nil {
+		sale := map[string]interface{}{"term": map[string]interface{}{"isSale": true}}
+		if !*q.IsSale {
+			sale = map[string]interface{}{"bool": map[string]interface{}{"must_not": sale}}
+		}
+		filters[facetSale] = sale
+	}
+
+	return filters
+}
+
+// filtersExcept combines every facet filter but the named one
+func filtersExcept(filters map[string]map[string]interface{}, except string) map[string]interface{} {
+	clauses := []interface{}{}
+	for name, clause := range filters {
+		if name != except {
+			clauses = append(clauses, clause)
+		}
+	}
+	return map[string]interface{}{"bool": map[string]interface{}{"filter": clauses}}
+}
+
+// Search runs a full-text search with facet filters. Filters are applied as
+// a post_filter so that each facet's counts ignore that facet's own filter.
+func (s *SearchService) Search(ctx context.Context, q models.SearchQuery) (*models.SearchResult, error) {
+	filters := facetFilters(q)
+
+	facetAgg := func(name string, agg map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"filter": filtersExcept(filters, name),
+			"aggs":   map[string]interface{}{"values": agg},
+		}
+	}
+
+	ranges := make([]map[string]interface{}, len(models.PriceRanges))
+	for i, r := range models.PriceRanges {
+		bucket := map[string]interface{}{"key": r.Key}
+		if r.From != nil {
+			bucket["from"] = *r.From
+		}
+		if r.To != nil {
+			bucket["to"] = *r.To
+		}
+		ranges[i] = bucket
+	}
+
+	searchQuery := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must":     textQuery(q.Text),
+				"must_not": map[string]interface{}{"term": map[string]interface{}{"isArchived": true}},
+			},
+		},
+		"post_filter": filtersExcept(filters, ""),
+		"aggs": map[string]interface{}{
+			facetCategories: facetAgg(facetCategories, map[string]interface{}{"terms": map[string]interface{}{"field": "category", "size": 50}}),
+			facetSizes:      facetAgg(facetSizes, map[string]interface{}{"terms": map[string]interface{}{"field": "sizes", "size": 50}}),
+			facetColors:     facetAgg(facetColors, map[string]interface{}{"terms": map[string]interface{}{"field": "colors.name", "size": 50}}),
+			facetPrices:     facetAgg(facetPrices, map[string]interface{}{"range": map[string]interface{}{"field": "price", "ranges": ranges}}),
+			facetSale:       facetAgg(facetSale, map[string]interface{}{"filter": map[string]interface{}{"term": map[string]interface{}{"isSale": true}}}),
+		},
+		"highlight": map[string]interface{}{
+			"pre_tags":  []string{"<em>"},
+			"post_tags": []string{"</em>"},
+			"fields": map[string]interface{}{
+				"name":        map[string]interface{}{"number_of_fragments": 0},
+				"description": map[string]interface{}{"fragment_size": 150, "number_of_fragments": 2},
+			},
+		},
+		"from":             (q.Page - 1) * q.Limit,
+		"size":             q.Limit,
+		"track_total_hits": true,
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(searchQuery); err != nil {
+		return nil, err
+	}
+
+	res, err := s.client.Search(
+		s.client.Search.WithContext(ctx),
+		s.client.Search.WithIndex(s.index),
+		s.client.Search.WithBody(&buf),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("search error: %s", res.String())
+	}
+
+	var response struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				ID        string              `json:"_id"`
+				Source    models.Product      `json:"_source"`
+				Highlight map[string][]string `json:"highlight"`
+			} `json:"hits"`
+		} `json:"hits"`
+		Aggregations struct {
+			Categories termsAggregation `json:"categories"`
+			Sizes      termsAggregation `json:"sizes"`
+			Colors     termsAggregation `json:"colors"`
+			Prices     struct {
+				Values struct {
+					Buckets []struct {
+						Key      string `json:"key"`
+						DocCount int64  `json:"doc_count"`
+					} `json:"buckets"`
+				} `json:"values"`
+			} `json:"prices"`
+			Sale struct {
+				Values struct {
+					DocCount int64 `json:"doc_count"`
+				} `json:"values"`
+			} `json:"sale"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	result := &models.SearchResult{
+		Products:   make([]models.Product, len(response.Hits.Hits)),
+		Total:      response.Hits.Total.Value,
+		Highlights: map[string]map[string][]string{},
+		Facets: models.SearchFacets{
+			Categories: response.Aggregations.Categories.buckets(),
+			Sizes:      response.Aggregations.Sizes.buckets(),
+			Colors:     response.Aggregations.Colors.buckets(),
+			OnSale:     response.Aggregations.Sale.Values.DocCount,
+		},
+	}
+	for i, hit := range response.Hits.Hits {
+		result.Products[i] = hit.Source
+		if len(hit.Highlight) > 0 {
+			result.Highlights[hit.ID] = hit.Highlight
+		}
+	}
+
+	counts := map[string]int64{}
+	for _, b := range response.Aggregations.Prices.Values.Buckets {
+		counts[b.Key] = b.DocCount
+	}
+	for _, r := range models.PriceRanges {
+		r.Count = counts[r.Key]
+		result.Facets.Prices = append(result.Facets.Prices, r)
+	}
+
+	return result, nil
+}
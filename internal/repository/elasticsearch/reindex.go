@@ -0,0 +1,426 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/khusa-mahal/backend/internal/models"
+)
+
+// versionLayout timestamps versioned index names, which therefore sort by age
+const versionLayout = "20060102150405"
+
+const bulkBatchSize = 500
+
+// ErrNoPreviousIndex is returned by Rollback when the alias already points at
+// the oldest remaining version
+var ErrNoPreviousIndex = errors.New("no previous index version to roll back to")
+
+// Alias is the name the server reads and writes through
+func (s *SearchService) Alias() string {
+	return s.index
+}
+
+// VersionTime returns when a versioned index was created, from its name
+func (s *SearchService) VersionTime(index string) (time.Time, bool) {
+	t, err := time.Parse(versionLayout, strings.TrimPrefix(index, s.index+"_v"))
+	return t, err == nil && strings.HasPrefix(index, s.index+"_v")
+}
+
+// CreateVersionedIndex creates an empty index named <alias>_v<timestamp>
+// with the current settings and mappings. It is not attached to the alias.
+func (s *SearchService) CreateVersionedIndex(ctx context.Context) (string, error) {
+	if err := s.ensureSynonymsSet(ctx); err != nil {
+		return "", err
+	}
+
+	name := s.index + "_v" + time.Now().UTC().Format(versionLayout)
+	res, err := s.client.Indices.Create(
+		name,
+		s.client.Indices.Create.WithContext(ctx),
+		s.client.Indices.Create.WithBody(strings.NewReader(indexDefinition)),
+	)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return "", fmt.Errorf("error creating index %s: %s", name, res.String())
+	}
+	return name, nil
+}
+
+// BulkIndex writes products into index in batches using the bulk API
+func (s *SearchService) BulkIndex(ctx context.Context, index string, products []models.Product) error {
+	for start := 0; start < len(products); start += bulkBatchSize {
+		end := start + bulkBatchSize
+		if end > len(products) {
+			end = len(products)
+		}
+
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for i := start; i < end; i++ {
+			action := map[string]interface{}{"index": map[string]interface{}{"_index": index, "_id": products[i].ID.Hex()}}
+			if err := enc.Encode(action); err != nil {
+				return err
+			}
+			if err := enc.Encode(&products[i]); err != nil {
+				return err
+			}
+		}
+
+		if err := s.bulk(ctx, &buf); err != nil {
+			return fmt.Errorf("bulk indexing products %d-%d: %w", start, end-1, err)
+		}
+	}
+	return nil
+}
+
+func (s *SearchService) bulk(ctx context.Context, body *bytes.Buffer) error {
+	res, err := s.client.Bulk(body, s.client.Bulk.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("bulk error: %s", res.String())
+	}
+
+	var response struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID     string `json:"_id"`
+			Status int    `json:"status"`
+			Error  *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return err
+	}
+	if !response.Errors {
+		return nil
+	}
+
+	var failures []string
+	for _, item := range response.Items {
+		for _, result := range item {
+			if result.Error != nil {
+				failures = append(failures, fmt.Sprintf("%s: %s: %s", result.ID, result.Error.Type, result.Error.Reason))
+			}
+		}
+	}
+	if len(failures) > 5 {
+		failures = append(failures[:5], fmt.Sprintf("and %d more", len(failures)-5))
+	}
+	return fmt.Errorf("%s", strings.Join(failures, "; "))
+}
+
+// Count refreshes index and returns how many documents it holds
+func (s *SearchService) Count(ctx context.Context, index string) (int64, error) {
+	res, err := s.client.Indices.Refresh(
+		s.client.Indices.Refresh.WithContext(ctx),
+		s.client.Indices.Refresh.WithIndex(index),
+	)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	if res.IsError() {
+		return 0, fmt.Errorf("error refreshing %s: %s", index, res.String())
+	}
+
+	res, err = s.client.Count(
+		s.client.Count.WithContext(ctx),
+		s.client.Count.WithIndex(index),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return 0, fmt.Errorf("error counting %s: %s", index, res.String())
+	}
+
+	var response struct {
+		Count int64 `json:"count"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return 0, err
+	}
+	return response.Count, nil
+}
+
+// AliasTargets returns the indices behind the alias. legacy reports a
+// concrete index that has the alias's name, as created before aliases were
+// used.
+func (s *SearchService) AliasTargets(ctx context.Context) (targets []string, legacy bool, err error) {
+	res, err := s.client.Indices.GetAlias(
+		s.client.Indices.GetAlias.WithContext(ctx),
+		s.client.Indices.GetAlias.WithName(s.index),
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != 404 {
+		if res.IsError() {
+			return nil, false, fmt.Errorf("error reading alias %s: %s", s.index, res.String())
+		}
+		var response map[string]json.RawMessage
+		if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+			return nil, false, err
+		}
+		for index := range response {
+			targets = append(targets, index)
+		}
+		sort.Strings(targets)
+		return targets, false, nil
+	}
+
+	exists, err := s.client.Indices.Exists([]string{s.index}, s.client.Indices.Exists.WithContext(ctx))
+	if err != nil {
+		return nil, false, err
+	}
+	exists.Body.Close()
+	return nil, exists.StatusCode == 200, nil
+}
+
+// SwapAlias points the alias at target in one atomic request, detaching it
+// from every other index. A legacy index named like the alias has to be
+// deleted in the same request, since the alias can't be created while it
+// exists, so it is first copied to a version that can be rolled back to and
+// pruned like any other. It returns the indices the alias pointed at before.
+func (s *SearchService) SwapAlias(ctx context.Context, target string) ([]string, error) {
+	previous, legacy, err := s.AliasTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := []map[string]interface{}{}
+	copied := ""
+	if legacy {
+		if copied, err = s.copyLegacy(ctx); err != nil {
+			return nil, err
+		}
+		actions = append(actions, map[string]interface{}{"remove_index": map[string]interface{}{"index": s.index}})
+	}
+	for _, index := range previous {
+		if index != target {
+			actions = append(actions, map[string]interface{}{"remove": map[string]interface{}{"index": index, "alias": s.index}})
+		}
+	}
+	actions = append(actions, map[string]interface{}{"add": map[string]interface{}{"index": target, "alias": s.index, "is_write_index": true}})
+
+	if err := s.updateAliases(ctx, actions); err != nil {
+		if legacy {
+			s.restoreLegacy(ctx, copied)
+		}
+		return nil, err
+	}
+	if legacy {
+		previous = []string{s.index}
+	}
+	return previous, nil
+}
+
+func (s *SearchService) updateAliases(ctx context.Context, actions []map[string]interface{}) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]interface{}{"actions": actions}); err != nil {
+		return err
+	}
+
+	res, err := s.client.Indices.UpdateAliases(&buf, s.client.Indices.UpdateAliases.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error swapping alias %s: %s", s.index, res.String())
+	}
+	return nil
+}
+
+// copyLegacy clones the legacy index to a version named after its creation
+// time, which sorts before every version built since. Writes to the legacy
+// index are blocked for the copy; the copy accepts them again.
+func (s *SearchService) copyLegacy(ctx context.Context) (string, error) {
+	created, err := s.creationTime(ctx, s.index)
+	if err != nil {
+		return "", err
+	}
+	name := s.index + "_v" + created.UTC().Format(versionLayout)
+
+	if err := s.blockWrites(ctx, s.index, true); err != nil {
+		return "", err
+	}
+	res, err := s.client.Indices.Clone(s.index, name,
+		s.client.Indices.Clone.WithContext(ctx),
+		s.client.Indices.Clone.WithBody(strings.NewReader(`{"settings": {"index.blocks.write": null}}`)),
+	)
+	if err == nil {
+		defer res.Body.Close()
+		if res.IsError() {
+			err = fmt.Errorf("error copying %s to %s: %s", s.index, name, res.String())
+		}
+	}
+	if err != nil {
+		s.restoreLegacy(ctx, "")
+		return "", err
+	}
+	return name, nil
+}
+
+// restoreLegacy undoes copyLegacy after a failed swap, so the legacy index
+// takes writes again and its copy isn't mistaken for a version
+func (s *SearchService) restoreLegacy(ctx context.Context, copied string) {
+	if err := s.blockWrites(ctx, s.index, false); err != nil {
+		fmt.Printf("⚠️  Failed to unblock writes to %s: %v\n", s.index, err)
+	}
+	if copied == "" {
+		return
+	}
+	if err := s.DeleteIndex(ctx, copied); err != nil {
+		fmt.Printf("⚠️  Failed to delete %s: %v\n", copied, err)
+	}
+}
+
+func (s *SearchService) blockWrites(ctx context.Context, index string, block bool) error {
+	body := fmt.Sprintf(`{"index.blocks.write": %t}`, block)
+	res, err := s.client.Indices.PutSettings(strings.NewReader(body),
+		s.client.Indices.PutSettings.WithContext(ctx),
+		s.client.Indices.PutSettings.WithIndex(index),
+	)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error setting write block on %s: %s", index, res.String())
+	}
+	return nil
+}
+
+// creationTime returns when index was created, from its settings
+func (s *SearchService) creationTime(ctx context.Context, index string) (time.Time, error) {
+	res, err := s.client.Indices.GetSettings(
+		s.client.Indices.GetSettings.WithContext(ctx),
+		s.client.Indices.GetSettings.WithIndex(index),
+		s.client.Indices.GetSettings.WithName("index.creation_date"),
+	)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return time.Time{}, fmt.Errorf("error reading settings of %s: %s", index, res.String())
+	}
+
+	var response map[string]struct {
+		Settings struct {
+			Index struct {
+				CreationDate string `json:"creation_date"`
+			} `json:"index"`
+		} `json:"settings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return time.Time{}, err
+	}
+	millis, err := strconv.ParseInt(response[index].Settings.Index.CreationDate, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("reading creation date of %s: %w", index, err)
+	}
+	return time.UnixMilli(millis), nil
+}
+
+// Versions lists the versioned indices for the alias, oldest first
+func (s *SearchService) Versions(ctx context.Context) ([]string, error) {
+	res, err := s.client.Cat.Indices(
+		s.client.Cat.Indices.WithContext(ctx),
+		s.client.Cat.Indices.WithIndex(s.index+"_v*"),
+		s.client.Cat.Indices.WithH("index"),
+		s.client.Cat.Indices.WithFormat("json"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("error listing indices: %s", res.String())
+	}
+
+	var response []struct {
+		Index string `json:"index"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	versions := []string{}
+	for _, entry := range response {
+		if _, ok := s.VersionTime(entry.Index); ok {
+			versions = append(versions, entry.Index)
+		}
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
+
+// PreviousVersion returns the newest version older than the one the alias
+// points at now
+func (s *SearchService) PreviousVersion(ctx context.Context) (current, previous string, err error) {
+	targets, _, err := s.AliasTargets(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	if len(targets) != 1 {
+		return "", "", fmt.Errorf("alias %s points at %d indices, expected 1", s.index, len(targets))
+	}
+	current = targets[0]
+
+	versions, err := s.Versions(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	for _, version := range versions {
+		if version < current {
+			previous = version
+		}
+	}
+	if previous == "" {
+		return current, "", ErrNoPreviousIndex
+	}
+	return current, previous, nil
+}
+
+// DeleteIndex removes an index
+func (s *SearchService) DeleteIndex(ctx context.Context, index string) error {
+	res, err := s.client.Indices.Delete([]string{index}, s.client.Indices.Delete.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error deleting index %s: %s", index, res.String())
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/elastic/go-elasticsearch/v8"
@@ -12,6 +13,9 @@ import (
 	"github.com/khusa-mahal/backend/internal/models"
 )
 
+// ErrNotFound is returned when a document isn't in the index
+var ErrNotFound = errors.New("document not found")
+
 type SearchService struct {
 	client *elasticsearch.Client
 	index  string
@@ -31,6 +35,11 @@ func NewSearchService(cfg *config.Config) (*SearchService, error) {
 	}, nil
 }
 
+// Name identifies the service as a search backend
+func (s *SearchService) Name() string {
+	return "elasticsearch"
+}
+
 // IndexProduct indexes a product for search
 func (s *SearchService) IndexProduct(ctx context.Context, product *models.Product) error {
 	data, err := json.Marshal(product)
@@ -62,15 +71,9 @@ func (s *SearchService) IndexProduct(ctx context.Context, product *models.Produc
 func (s *SearchService) SearchProducts(ctx context.Context, query string, from, size int) ([]models.Product, error) {
 	var buf bytes.Buffer
 	searchQuery := map[string]interface{}{
-		"query": map[string]interface{}{
-			"multi_match": map[string]interface{}{
-				"query":     query,
-				"fields":    []string{"name^3", "description", "category^2"},
-				"fuzziness": "AUTO",
-			},
-		},
-		"from": from,
-		"size": size,
+		"query": textQuery(query),
+		"from":  from,
+		"size":  size,
 	}
 
 	if err := json.NewEncoder(&buf).Encode(searchQuery); err != nil {
@@ -124,6 +127,9 @@ func (s *SearchService) DeleteProduct(ctx context.Context, id string) error {
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode == 404 {
+		return ErrNotFound
+	}
 	if res.IsError() {
 		return fmt.Errorf("error deleting product: %s", res.String())
 	}
@@ -131,42 +137,161 @@ func (s *SearchService) DeleteProduct(ctx context.Context, id string) error {
 	return nil
 }
 
-// CreateIndex creates the products index with mappings
-func (s *SearchService) CreateIndex(ctx context.Context) error {
-	mapping := `{
-		"mappings": {
-			"properties": {
-				"name": {
-					"type": "text",
-					"analyzer": "standard"
+// indexDefinition holds the settings and mappings of a products index.
+//
+// Product text goes through khusa_text: lowercasing, Arabic/Persian script
+// normalization (so Urdu spellings with different letter forms or diacritics
+// match), ASCII folding, and roman_fold, which collapses repeated letters so
+// "khussa" and "khusa" index the same. khusa_search adds the managed synonyms
+// on top at query time.
+const indexDefinition = `{
+	"settings": {
+		"analysis": {
+			"filter": {
+				"roman_fold": {
+					"type": "pattern_replace",
+					"pattern": "(\\p{L})\\1+",
+					"replacement": "$1"
 				},
-				"description": {
-					"type": "text"
+				"khusa_synonyms": {
+					"type": "synonym_graph",
+					"synonyms_set": "` + SynonymsSetID + `",
+					"updateable": true
 				},
-				"category": {
-					"type": "keyword"
+				"autocomplete_filter": {
+					"type": "edge_ngram",
+					"min_gram": 1,
+					"max_gram": 20
+				}
+			},
+			"analyzer": {
+				"khusa_text": {
+					"type": "custom",
+					"tokenizer": "standard",
+					"filter": ["lowercase", "decimal_digit", "arabic_normalization", "persian_normalization", "asciifolding", "roman_fold"]
+				},
+				"khusa_search": {
+					"type": "custom",
+					"tokenizer": "standard",
+					"filter": ["lowercase", "decimal_digit", "arabic_normalization", "persian_normalization", "asciifolding", "roman_fold", "khusa_synonyms"]
 				},
-				"price": {
-					"type": "float"
+				"autocomplete": {
+					"type": "custom",
+					"tokenizer": "standard",
+					"filter": ["lowercase", "arabic_normalization", "persian_normalization", "asciifolding", "roman_fold", "autocomplete_filter"]
+				},
+				"autocomplete_search": {
+					"type": "custom",
+					"tokenizer": "standard",
+					"filter": ["lowercase", "arabic_normalization", "persian_normalization", "asciifolding", "roman_fold"]
 				}
 			}
 		}
-	}`
+	},
+	"mappings": {
+		"properties": {
+			"name": {
+				"type": "text",
+				"analyzer": "khusa_text",
+				"search_analyzer": "khusa_search",
+				"fields": {
+					"autocomplete": {
+						"type": "text",
+						"analyzer": "autocomplete",
+						"search_analyzer": "autocomplete_search"
+					}
+				}
+			},
+			"nameUrdu": {
+				"type": "text",
+				"analyzer": "khusa_text",
+				"search_analyzer": "khusa_search"
+			},
+			"description": {
+				"type": "text",
+				"analyzer": "khusa_text",
+				"search_analyzer": "khusa_search"
+			},
+			"category": {
+				"type": "keyword",
+				"fields": {
+					"text": {
+						"type": "text",
+						"analyzer": "khusa_text",
+						"search_analyzer": "khusa_search"
+					},
+					"autocomplete": {
+						"type": "text",
+						"analyzer": "autocomplete",
+						"search_analyzer": "autocomplete_search"
+					}
+				}
+			},
+			"categoryId": {
+				"type": "keyword"
+			},
+			"price": {
+				"type": "float"
+			},
+			"sizes": {
+				"type": "keyword"
+			},
+			"colors": {
+				"properties": {
+					"name": {"type": "keyword"},
+					"hex": {"type": "keyword", "index": false}
+				}
+			},
+			"rating": {
+				"type": "float"
+			},
+			"reviews": {
+				"type": "integer"
+			},
+			"stock": {
+				"type": "integer"
+			},
+			"soldCount": {
+				"type": "integer"
+			},
+			"isSale": {
+				"type": "boolean"
+			},
+			"isNew": {
+				"type": "boolean"
+			},
+			"isArchived": {
+				"type": "boolean"
+			},
+			"createdAt": {
+				"type": "date"
+			}
+		}
+	}
+}`
 
-	res, err := s.client.Indices.Create(
-		s.index,
-		s.client.Indices.Create.WithContext(ctx),
-		s.client.Indices.Create.WithBody(bytes.NewReader([]byte(mapping))),
-	)
-	if err != nil {
+// CreateIndex makes sure the products alias exists. On a fresh cluster it
+// creates the first versioned index behind the alias; an existing alias, or a
+// legacy index with the alias's name, is left as it is. Mapping changes are
+// rolled out with cmd/reindex.
+func (s *SearchService) CreateIndex(ctx context.Context) error {
+	if err := s.ensureSynonymsSet(ctx); err != nil {
 		return err
 	}
-	defer res.Body.Close()
 
-	if res.IsError() {
-		// Index might already exist, which is okay
+	res, err := s.client.Indices.Exists([]string{s.index}, s.client.Indices.Exists.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode == 200 {
 		return nil
 	}
 
-	return nil
+	name, err := s.CreateVersionedIndex(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = s.SwapAlias(ctx, name)
+	return err
 }
@@ -0,0 +1,104 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/khusa-mahal/backend/internal/models"
+)
+
+// maxCategorySuggestions caps how many of the suggestions may be categories
+const maxCategorySuggestions = 3
+
+// Suggest returns up to size autocomplete entries for a partly typed query,
+// matching word prefixes of product names and categories through the
+// edge-ngram autocomplete fields. Matching categories come first.
+func (s *SearchService) Suggest(ctx context.Context, prefix string, size int) ([]models.Suggestion, error) {
+	categoryMatch := map[string]interface{}{
+		"match": map[string]interface{}{
+			"category.autocomplete": map[string]interface{}{"query": prefix, "operator": "and"},
+		},
+	}
+
+	suggestQuery := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should": []interface{}{
+					map[string]interface{}{
+						"match": map[string]interface{}{
+							"name.autocomplete": map[string]interface{}{"query": prefix, "operator": "and"},
+						},
+					},
+					categoryMatch,
+				},
+				"minimum_should_match": 1,
+				"must_not":             map[string]interface{}{"term": map[string]interface{}{"isArchived": true}},
+			},
+		},
+		"aggs": map[string]interface{}{
+			"categories": map[string]interface{}{
+				"filter": categoryMatch,
+				"aggs": map[string]interface{}{
+					"values": map[string]interface{}{"terms": map[string]interface{}{"field": "category", "size": maxCategorySuggestions}},
+				},
+			},
+		},
+		"_source": []string{"id", "name", "category", "image", "price"},
+		"size":    size,
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(suggestQuery); err != nil {
+		return nil, err
+	}
+
+	res, err := s.client.Search(
+		s.client.Search.WithContext(ctx),
+		s.client.Search.WithIndex(s.index),
+		s.client.Search.WithBody(&buf),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("suggest error: %s", res.String())
+	}
+
+	var response struct {
+		Hits struct {
+			Hits []struct {
+				ID     string         `json:"_id"`
+				Source models.Product `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+		Aggregations struct {
+			Categories termsAggregation `json:"categories"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	suggestions := []models.Suggestion{}
+	for _, bucket := range response.Aggregations.Categories.buckets() {
+		suggestions = append(suggestions, models.Suggestion{Type: models.SuggestionCategory, Text: bucket.Value})
+	}
+	for _, hit := range response.Hits.Hits {
+		if len(suggestions) >= size {
+			break
+		}
+		suggestions = append(suggestions, models.Suggestion{
+			Type:      models.SuggestionProduct,
+			Text:      hit.Source.Name,
+			ProductID: hit.ID,
+			Category:  hit.Source.Category,
+			Image:     hit.Source.Image,
+			Price:     hit.Source.Price,
+		})
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,99 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/khusa-mahal/backend/internal/models"
+)
+
+// SynonymsSetID is the Elasticsearch synonyms set used by the search analyzer
+const SynonymsSetID = "khusa-synonyms"
+
+// PutSynonyms replaces the synonyms set with the given groups. Elasticsearch
+// reloads every search analyzer using the set, so the change applies to the
+// next query without reindexing.
+func (s *SearchService) PutSynonyms(ctx context.Context, groups []models.SynonymGroup) error {
+	rules := make([]map[string]string, 0, len(groups))
+	for _, group := range groups {
+		rules = append(rules, map[string]string{
+			"id":       group.ID.Hex(),
+			"synonyms": strings.Join(group.Terms, ", "),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]interface{}{"synonyms_set": rules}); err != nil {
+		return err
+	}
+
+	res, err := s.client.SynonymsPutSynonym(
+		SynonymsSetID,
+		&buf,
+		s.client.SynonymsPutSynonym.WithContext(ctx),
+	)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error updating synonyms: %s", res.String())
+	}
+	return nil
+}
+
+// ensureSynonymsSet creates an empty synonyms set if there is none yet, since
+// an index whose analyzer names a missing set can't be created
+func (s *SearchService) ensureSynonymsSet(ctx context.Context) error {
+	res, err := s.client.SynonymsGetSynonym(
+		SynonymsSetID,
+		s.client.SynonymsGetSynonym.WithContext(ctx),
+		s.client.SynonymsGetSynonym.WithSize(1),
+	)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return s.PutSynonyms(ctx, nil)
+	}
+	if res.IsError() {
+		return fmt.Errorf("error reading synonyms: %s", res.String())
+	}
+	return nil
+}
+
+// textQuery matches the search text against the product text fields twice:
+// exactly through the synonym-aware search analyzer, and fuzzily without
+// synonyms to absorb typos. Fuzziness can't be combined with multi-word
+// synonym graphs, hence the split.
+func textQuery(text string) map[string]interface{} {
+	fields := []string{"name^3", "nameUrdu^3", "category.text^2", "description"}
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should": []interface{}{
+				map[string]interface{}{
+					"multi_match": map[string]interface{}{
+						"query":  text,
+						"fields": fields,
+						"boost":  2,
+					},
+				},
+				map[string]interface{}{
+					"multi_match": map[string]interface{}{
+						"query":     text,
+						"fields":    fields,
+						"analyzer":  "khusa_text",
+						"fuzziness": "AUTO",
+					},
+				},
+			},
+			"minimum_should_match": 1,
+		},
+	}
+}
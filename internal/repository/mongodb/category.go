@@ -0,0 +1,116 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CategoryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCategoryRepository(db *mongo.Database) *CategoryRepository {
+	return &CategoryRepository{
+		collection: db.Collection("categories"),
+	}
+}
+
+// CreateIndexes makes slugs unique
+func (r *CategoryRepository) CreateIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "slug", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *CategoryRepository) FindAll(ctx context.Context) ([]models.Category, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	categories := []models.Category{}
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r *CategoryRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Category, error) {
+	var category models.Category
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&category); err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *CategoryRepository) FindBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	var category models.Category
+	if err := r.collection.FindOne(ctx, bson.M{"slug": slug}).Decode(&category); err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// Create stores a new category. A taken slug fails with a duplicate key error.
+func (r *CategoryRepository) Create(ctx context.Context, category *models.Category) error {
+	category.CreatedAt = time.Now()
+	category.UpdatedAt = category.CreatedAt
+	result, err := r.collection.InsertOne(ctx, category)
+	if err != nil {
+		return err
+	}
+	category.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// Ensure returns the category with the name's slug, creating it if needed
+func (r *CategoryRepository) Ensure(ctx context.Context, name string) (*models.Category, error) {
+	now := time.Now()
+	slug := models.Slugify(name)
+	update := bson.M{"$setOnInsert": bson.M{
+		"name":      name,
+		"slug":      slug,
+		"createdAt": now,
+		"updatedAt": now,
+	}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var category models.Category
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"slug": slug}, update, opts).Decode(&category); err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// Update sets the given fields on a category
+func (r *CategoryRepository) Update(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	fields["updatedAt"] = time.Now()
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *CategoryRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -68,14 +68,60 @@ func (r *OrderRepository) Update(ctx context.Context, order *models.Order) error
 	return err
 }
 
-func (r *OrderRepository) UpdateStatus(ctx context.Context, orderID primitive.ObjectID, status string, paymentStatus string) error {
+// FindAll lists orders newest first, optionally restricted to one status
+func (r *OrderRepository) FindAll(ctx context.Context, status string, limit int64) ([]models.Order, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	orders := []models.Order{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// TransitionStatus moves an order from one status to another and appends the
+// event to its timeline. The update only applies if the order is still in
+// the expected status, so concurrent transitions can't both succeed.
+func (r *OrderRepository) TransitionStatus(ctx context.Context, orderID primitive.ObjectID, from string, event models.OrderEvent, extra bson.M) (bool, error) {
+	set := bson.M{
+		"status":    event.Status,
+		"updatedAt": event.At,
+	}
+	for k, v := range extra {
+		set[k] = v
+	}
+
 	update := bson.M{
-		"$set": bson.M{
-			"status":        status,
-			"paymentStatus": paymentStatus,
-			"updatedAt":     time.Now(),
-		},
+		"$set":  set,
+		"$push": bson.M{"timeline": event},
 	}
-	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": orderID}, update)
-	return err
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": orderID, "status": from}, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// HasDeliveredProduct reports whether the user has a delivered order containing the product
+func (r *OrderRepository) HasDeliveredProduct(ctx context.Context, userID, productID primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"userId":          userID,
+		"status":          models.OrderStatusDelivered,
+		"items.productId": productID,
+	}
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/khusa-mahal/backend/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -31,25 +32,57 @@ func NewOTPRepository(db *mongo.Database) *OTPRepository {
 
 func (r *OTPRepository) Save(ctx context.Context, otp *models.OTP) error {
 	otp.CreatedAt = time.Now()
-	_, err := r.collection.InsertOne(ctx, otp)
-	return err
+	result, err := r.collection.InsertOne(ctx, otp)
+	if err != nil {
+		return err
+	}
+	otp.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
 }
 
-func (r *OTPRepository) FindValidOTP(ctx context.Context, email, code string) (*models.OTP, error) {
-	var otp models.OTP
+// purposeFilter matches OTPs for purpose. Signup codes issued before purposes
+// existed have no purpose field.
+func purposeFilter(purpose string) interface{} {
+	if purpose == models.OTPPurposeSignup {
+		return bson.M{"$in": bson.A{purpose, nil}}
+	}
+	return purpose
+}
+
+// ClaimAttempt counts one verification attempt against the newest unexpired
+// code and returns it. ErrNoDocuments means there is no code or it has used up
+// maxAttempts. Claiming before comparing stops parallel guesses from sharing
+// one attempt.
+func (r *OTPRepository) ClaimAttempt(ctx context.Context, email, purpose string, maxAttempts int) (*models.OTP, error) {
 	filter := bson.M{
 		"email":     email,
-		"code":      code,
+		"purpose":   purposeFilter(purpose),
 		"expiresAt": bson.M{"$gt": time.Now()},
+		"attempts":  bson.M{"$not": bson.M{"$gte": maxAttempts}},
 	}
-	err := r.collection.FindOne(ctx, filter).Decode(&otp)
-	if err != nil {
+	update := bson.M{"$inc": bson.M{"attempts": 1}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetReturnDocument(options.After)
+
+	var otp models.OTP
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&otp); err != nil {
 		return nil, err
 	}
 	return &otp, nil
 }
 
-func (r *OTPRepository) DeleteByEmail(ctx context.Context, email string) error {
-	_, err := r.collection.DeleteMany(ctx, bson.M{"email": email})
+// Consume deletes a code after a successful verification. It returns false if
+// the code was already used by a concurrent request.
+func (r *OTPRepository) Consume(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+func (r *OTPRepository) DeleteByEmail(ctx context.Context, email, purpose string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"email": email, "purpose": purposeFilter(purpose)})
 	return err
 }
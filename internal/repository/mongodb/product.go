@@ -2,6 +2,9 @@ package mongodb
 
 import (
 	"context"
+	"errors"
+	"regexp"
+	"slices"
 	"time"
 
 	"github.com/khusa-mahal/backend/internal/config"
@@ -14,14 +17,105 @@ import (
 
 type ProductRepository struct {
 	collection *mongo.Collection
+	categories *mongo.Collection // For the slugs of changed categories
+	listener   ProductListener
+}
+
+// ProductListener is told about every successful product write, so caches
+// holding copies of the products can drop them
+type ProductListener interface {
+	ProductsChanged(ctx context.Context, change models.ProductChange)
 }
 
 func NewProductRepository(db *mongo.Database) *ProductRepository {
 	return &ProductRepository{
 		collection: db.Collection("products"),
+		categories: db.Collection("categories"),
 	}
 }
 
+// SetListener registers the listener notified after product writes
+func (r *ProductRepository) SetListener(listener ProductListener) {
+	r.listener = listener
+}
+
+// notify reports a write to ids. categories are the product categories the
+// write touched, before and after; when none are known, any category's
+// listings may have changed.
+func (r *ProductRepository) notify(ctx context.Context, ids []primitive.ObjectID, categories ...string) {
+	if r.listener == nil || len(ids) == 0 {
+		return
+	}
+
+	change := models.ProductChange{IDs: make([]string, len(ids))}
+	for i, id := range ids {
+		change.IDs[i] = id.Hex()
+	}
+	for _, category := range categories {
+		if category != "" && !slices.Contains(change.Categories, category) {
+			change.Categories = append(change.Categories, category)
+		}
+	}
+	if change.Categories != nil {
+		slugs, err := r.categorySlugs(ctx, change.Categories)
+		if err != nil {
+			// Without the pages' slugs every category is treated as changed
+			change.Categories = nil
+		}
+		change.CategorySlugs = slugs
+	}
+
+	r.listener.ProductsChanged(ctx, change)
+}
+
+// notifyStock reports a change to a product's stock alone. flipped reports
+// that some stock reached or left zero, which listings filtered on stock see.
+func (r *ProductRepository) notifyStock(ctx context.Context, id primitive.ObjectID, flipped bool) {
+	if r.listener == nil {
+		return
+	}
+	r.listener.ProductsChanged(ctx, models.ProductChange{
+		IDs:          []string{id.Hex()},
+		StockOnly:    true,
+		StockFlipped: flipped,
+	})
+}
+
+// CategoryPagesChanged reports that the category pages at slugs changed
+// without a product being written, as when a category's slug changes
+func (r *ProductRepository) CategoryPagesChanged(ctx context.Context, slugs ...string) {
+	if r.listener == nil || len(slugs) == 0 {
+		return
+	}
+	r.listener.ProductsChanged(ctx, models.ProductChange{CategorySlugs: slugs})
+}
+
+// categorySlugs returns the slugs of the categories with the given names.
+// Category pages list products by category ID or, before migration, by name,
+// so the name is enough to find every page a product appears on.
+func (r *ProductRepository) categorySlugs(ctx context.Context, names []string) ([]string, error) {
+	values, err := r.categories.Distinct(ctx, "slug", bson.M{"name": bson.M{"$in": names}})
+	if err != nil {
+		return nil, err
+	}
+
+	slugs := make([]string, 0, len(values))
+	for _, v := range values {
+		if slug, ok := v.(string); ok {
+			slugs = append(slugs, slug)
+		}
+	}
+	return slugs, nil
+}
+
+// categoryBefore is decoded from the pre-write document returned by
+// FindOneAndUpdate and FindOneAndDelete, for notify
+type categoryBefore struct {
+	Category string `bson:"category"`
+}
+
+var categoryProjection = bson.M{"category": 1}
+
 // GetAll retrieves all products with optional filtering
 func (r *ProductRepository) GetAll(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]models.Product, error) {
 	cursor, err := r.collection.Find(ctx, filter, opts...)
@@ -38,6 +132,23 @@ func (r *ProductRepository) GetAll(ctx context.Context, filter bson.M, opts ...*
 	return products, nil
 }
 
+// FindPage returns one page of products matching filter and the total number of matches
+func (r *ProductRepository) FindPage(ctx context.Context, filter bson.M, sort bson.D, skip, limit int64) ([]models.Product, int64, error) {
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	products, err := r.GetAll(ctx, filter, options.Find().SetSort(sort).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	if products == nil {
+		products = []models.Product{}
+	}
+	return products, total, nil
+}
+
 // GetByID retrieves a single product by ID
 func (r *ProductRepository) GetByID(ctx context.Context, id string) (*models.Product, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
@@ -70,6 +181,7 @@ func (r *ProductRepository) Create(ctx context.Context, product *models.Product)
 	}
 
 	product.ID = result.InsertedID.(primitive.ObjectID)
+	r.notify(ctx, []primitive.ObjectID{product.ID}, product.Category)
 	return nil
 }
 
@@ -83,8 +195,151 @@ func (r *ProductRepository) Update(ctx context.Context, id string, product *mode
 	product.UpdatedAt = time.Now()
 	update := bson.M{"$set": product}
 
-	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
-	return err
+	var before categoryBefore
+	err = r.collection.FindOneAndUpdate(ctx, bson.M{"_id": objectID}, update,
+		options.FindOneAndUpdate().SetProjection(categoryProjection),
+	).Decode(&before)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	r.notify(ctx, []primitive.ObjectID{objectID}, before.Category, product.Category)
+	return nil
+}
+
+// UpdateFields sets only the given fields on a product
+func (r *ProductRepository) UpdateFields(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	fields["updatedAt"] = time.Now()
+	var before categoryBefore
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{"$set": fields},
+		options.FindOneAndUpdate().SetProjection(categoryProjection),
+	).Decode(&before)
+	if err != nil {
+		return err
+	}
+
+	category, _ := fields["category"].(string)
+	r.notify(ctx, []primitive.ObjectID{id}, before.Category, category)
+	return nil
+}
+
+// UpdateFieldsIfUnchanged is UpdateFields for fields computed from the
+// product as read with the given updatedAt. It only applies if no write has
+// touched the product since, so stock reserved in between isn't overwritten,
+// and reports whether it did.
+func (r *ProductRepository) UpdateFieldsIfUnchanged(ctx context.Context, id primitive.ObjectID, updatedAt time.Time, fields bson.M) (bool, error) {
+	filter := bson.M{"_id": id, "updatedAt": updatedAt}
+	if updatedAt.IsZero() {
+		filter["updatedAt"] = bson.M{"$exists": false}
+	}
+
+	fields["updatedAt"] = time.Now()
+	var before categoryBefore
+	err := r.collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": fields},
+		options.FindOneAndUpdate().SetProjection(categoryProjection),
+	).Decode(&before)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	category, _ := fields["category"].(string)
+	r.notify(ctx, []primitive.ObjectID{id}, before.Category, category)
+	return true, nil
+}
+
+// UpdateRating stores the average rating and number of reviews
+func (r *ProductRepository) UpdateRating(ctx context.Context, id primitive.ObjectID, rating float64, count int) error {
+	return r.UpdateFields(ctx, id, bson.M{"rating": rating, "reviews": count})
+}
+
+// CountByCategory counts products linked to a category, archived ones included
+func (r *ProductRepository) CountByCategory(ctx context.Context, categoryID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"categoryId": categoryID})
+}
+
+// RenameCategory updates the category name on every product linked to the
+// category and returns their IDs
+func (r *ProductRepository) RenameCategory(ctx context.Context, categoryID primitive.ObjectID, name string) ([]primitive.ObjectID, error) {
+	ids, err := r.findIDs(ctx, bson.M{"categoryId": categoryID})
+	if err != nil || len(ids) == 0 {
+		return ids, err
+	}
+
+	_, err = r.collection.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": ids}},
+		bson.M{"$set": bson.M{"category": name, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		return ids, err
+	}
+
+	// The old name isn't known here, so every listing is treated as stale
+	r.notify(ctx, ids)
+	return ids, nil
+}
+
+// UnlinkedCategoryNames returns the distinct category names of products that
+// have no category ID yet
+func (r *ProductRepository) UnlinkedCategoryNames(ctx context.Context) ([]string, error) {
+	values, err := r.collection.Distinct(ctx, "category", bson.M{"categoryId": bson.M{"$exists": false}})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(values))
+	for _, v := range values {
+		if name, ok := v.(string); ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// LinkCategory points unlinked products with the given category name at the
+// category and returns their IDs
+func (r *ProductRepository) LinkCategory(ctx context.Context, name string, category *models.Category) ([]primitive.ObjectID, error) {
+	ids, err := r.findIDs(ctx, bson.M{"category": name, "categoryId": bson.M{"$exists": false}})
+	if err != nil || len(ids) == 0 {
+		return ids, err
+	}
+
+	_, err = r.collection.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": ids}},
+		bson.M{"$set": bson.M{"categoryId": category.ID, "category": category.Name, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		return ids, err
+	}
+
+	r.notify(ctx, ids, name, category.Name)
+	return ids, nil
+}
+
+func (r *ProductRepository) findIDs(ctx context.Context, filter bson.M) ([]primitive.ObjectID, error) {
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	return ids, nil
 }
 
 // Delete deletes a product
@@ -94,29 +349,210 @@ func (r *ProductRepository) Delete(ctx context.Context, id string) error {
 		return err
 	}
 
-	_, err = r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	var before categoryBefore
+	err = r.collection.FindOneAndDelete(ctx, bson.M{"_id": objectID},
+		options.FindOneAndDelete().SetProjection(categoryProjection),
+	).Decode(&before)
+	if err != nil {
+		return err
+	}
+
+	r.notify(ctx, []primitive.ObjectID{objectID}, before.Category)
+	return nil
+}
+
+// DeleteMany deletes the given products and returns how many were removed
+func (r *ProductRepository) DeleteMany(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+
+	r.notify(ctx, ids)
+	return result.DeletedCount, nil
+}
+
+// ErrInsufficientStock is returned when a reservation would take stock below zero
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ReserveStock atomically decrements stock for one variant of a product.
+// Products created before variants existed only track product-level stock.
+func (r *ProductRepository) ReserveStock(ctx context.Context, productID primitive.ObjectID, size, color string, quantity int) error {
+	filter := bson.M{
+		"_id": productID,
+		"variants": bson.M{"$elemMatch": bson.M{
+			"size":  size,
+			"color": color,
+			"stock": bson.M{"$gte": quantity},
+		}},
+	}
+	update := bson.M{
+		"$inc": bson.M{"variants.$.stock": -quantity, "stock": -quantity, "soldCount": quantity},
+		"$set": bson.M{"updatedAt": time.Now()},
+	}
+
+	matched, err := r.updateStock(ctx, filter, update, -quantity)
+	if err != nil || matched {
+		return err
+	}
+
+	filter = bson.M{
+		"_id":        productID,
+		"variants.0": bson.M{"$exists": false},
+		"stock":      bson.M{"$gte": quantity},
+	}
+	update = bson.M{
+		"$inc": bson.M{"stock": -quantity, "soldCount": quantity},
+		"$set": bson.M{"updatedAt": time.Now()},
+	}
+
+	matched, err = r.updateStock(ctx, filter, update, -quantity)
+	if err != nil {
+		return err
+	}
+	if !matched {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// ReleaseStock returns previously reserved stock for a product variant
+func (r *ProductRepository) ReleaseStock(ctx context.Context, productID primitive.ObjectID, size, color string, quantity int) error {
+	filter := bson.M{
+		"_id":      productID,
+		"variants": bson.M{"$elemMatch": bson.M{"size": size, "color": color}},
+	}
+	update := bson.M{
+		"$inc": bson.M{"variants.$.stock": quantity, "stock": quantity, "soldCount": -quantity},
+		"$set": bson.M{"updatedAt": time.Now()},
+	}
+
+	matched, err := r.updateStock(ctx, filter, update, quantity)
+	if err != nil || matched {
+		return err
+	}
+
+	filter = bson.M{"_id": productID, "variants.0": bson.M{"$exists": false}}
+	update = bson.M{
+		"$inc": bson.M{"stock": quantity, "soldCount": -quantity},
+		"$set": bson.M{"updatedAt": time.Now()},
+	}
+	_, err = r.updateStock(ctx, filter, update, quantity)
 	return err
 }
 
-// Search performs text search on products
+// updateStock applies a stock update of delta units to the product matching
+// filter, if any, and reports whether one matched. A filter matching a
+// variant updates that variant's stock and the product's total.
+func (r *ProductRepository) updateStock(ctx context.Context, filter, update bson.M, delta int) (bool, error) {
+	projection := bson.M{"stock": 1}
+	if _, ok := filter["variants"]; ok {
+		projection = bson.M{"variants.$": 1}
+	}
+
+	var before struct {
+		ID       primitive.ObjectID `bson:"_id"`
+		Stock    int                `bson:"stock"`
+		Variants []models.Variant   `bson:"variants"`
+	}
+	err := r.collection.FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetProjection(projection),
+	).Decode(&before)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	stock := before.Stock
+	if len(before.Variants) == 1 {
+		stock = before.Variants[0].Stock
+	}
+	r.notifyStock(ctx, before.ID, (stock > 0) != (stock+delta > 0))
+	return true, nil
+}
+
+// Search matches the query as a case-insensitive substring of the product
+// text. The query is escaped, so regex syntax in user input matches literally.
 func (r *ProductRepository) Search(ctx context.Context, query string) ([]models.Product, error) {
+	pattern := primitive.Regex{Pattern: regexp.QuoteMeta(query), Options: "i"}
 	filter := bson.M{
+		"isArchived": bson.M{"$ne": true},
 		"$or": []bson.M{
-			{"name": bson.M{"$regex": query, "$options": "i"}},
-			{"description": bson.M{"$regex": query, "$options": "i"}},
-			{"category": bson.M{"$regex": query, "$options": "i"}},
+			{"name": pattern},
+			{"nameUrdu": pattern},
+			{"description": pattern},
+			{"category": pattern},
 		},
 	}
 
 	return r.GetAll(ctx, filter)
 }
 
+// TextSearch matches whole words through the name/description text index,
+// best matches first
+func (r *ProductRepository) TextSearch(ctx context.Context, query string) ([]models.Product, error) {
+	filter := bson.M{
+		"isArchived": bson.M{"$ne": true},
+		"$text":      bson.M{"$search": query},
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "_id", Value: 1}})
+
+	return r.GetAll(ctx, filter, opts)
+}
+
+// Suggest returns live products whose name has a word starting with prefix,
+// and the distinct categories that do, for autocomplete
+func (r *ProductRepository) Suggest(ctx context.Context, prefix string, limit int64) ([]models.Product, []string, error) {
+	pattern := primitive.Regex{Pattern: `(^|\s)` + regexp.QuoteMeta(prefix), Options: "i"}
+
+	products, err := r.GetAll(ctx,
+		bson.M{"isArchived": bson.M{"$ne": true}, "name": pattern},
+		options.Find().
+			SetProjection(bson.M{"name": 1, "category": 1, "image": 1, "price": 1}).
+			SetSort(bson.D{{Key: "soldCount", Value: -1}, {Key: "_id", Value: 1}}).
+			SetLimit(limit),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values, err := r.collection.Distinct(ctx, "category", bson.M{"isArchived": bson.M{"$ne": true}, "category": pattern})
+	if err != nil {
+		return nil, nil, err
+	}
+	categories := make([]string, 0, len(values))
+	for _, v := range values {
+		if name, ok := v.(string); ok && name != "" {
+			categories = append(categories, name)
+		}
+	}
+
+	return products, categories, nil
+}
+
+// Watch opens a change stream on the products collection, resuming after
+// token when one is given. Updates carry the current full document.
+func (r *ProductRepository) Watch(ctx context.Context, token bson.Raw) (*mongo.ChangeStream, error) {
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if token != nil {
+		opts.SetResumeAfter(token)
+	}
+	return r.collection.Watch(ctx, mongo.Pipeline{}, opts)
+}
+
 // CreateIndexes creates necessary indexes for optimal performance
 func (r *ProductRepository) CreateIndexes(ctx context.Context) error {
 	indexes := []mongo.IndexModel{
 		{Keys: bson.D{{Key: "category", Value: 1}}},
+		{Keys: bson.D{{Key: "categoryId", Value: 1}}},
 		{Keys: bson.D{{Key: "price", Value: 1}}},
 		{Keys: bson.D{{Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "rating", Value: -1}}},
+		{Keys: bson.D{{Key: "soldCount", Value: -1}}},
 		{Keys: bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}}},
 	}
 
@@ -0,0 +1,105 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ReviewRepository struct {
+	collection *mongo.Collection
+}
+
+func NewReviewRepository(db *mongo.Database) *ReviewRepository {
+	return &ReviewRepository{
+		collection: db.Collection("reviews"),
+	}
+}
+
+// CreateIndexes allows one review per user per product and serves newest-first listings
+func (r *ReviewRepository) CreateIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "productId", Value: 1}, {Key: "userId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "productId", Value: 1}, {Key: "createdAt", Value: -1}}},
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// Create stores a review. A second review of the same product by the same
+// user fails with a duplicate key error.
+func (r *ReviewRepository) Create(ctx context.Context, review *models.Review) error {
+	review.CreatedAt = time.Now()
+	result, err := r.collection.InsertOne(ctx, review)
+	if err != nil {
+		return err
+	}
+	review.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// FindByProduct returns a page of a product's reviews, newest first, and the total count
+func (r *ReviewRepository) FindByProduct(ctx context.Context, productID primitive.ObjectID, skip, limit int64) ([]models.Review, int64, error) {
+	filter := bson.M{"productId": productID}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(skip).
+		SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	reviews := []models.Review{}
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
+
+// Stats returns the average rating and number of reviews for a product
+func (r *ReviewRepository) Stats(ctx context.Context, productID primitive.ObjectID) (float64, int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"productId": productID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     nil,
+			"average": bson.M{"$avg": "$rating"},
+			"count":   bson.M{"$sum": 1},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var stats []struct {
+		Average float64 `bson:"average"`
+		Count   int     `bson:"count"`
+	}
+	if err := cursor.All(ctx, &stats); err != nil {
+		return 0, 0, err
+	}
+	if len(stats) == 0 {
+		return 0, 0, nil
+	}
+	return stats[0].Average, stats[0].Count, nil
+}
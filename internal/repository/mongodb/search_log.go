@@ -0,0 +1,153 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SearchLogRepository stores storefront searches for analytics
+type SearchLogRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSearchLogRepository(db *mongo.Database) *SearchLogRepository {
+	return &SearchLogRepository{
+		collection: db.Collection("search_queries"),
+	}
+}
+
+// QueryStat summarizes the searches for one term
+type QueryStat struct {
+	Term           string    `json:"term" bson:"_id"`
+	Searches       int64     `json:"searches" bson:"searches"`
+	AvgResults     float64   `json:"avgResults" bson:"avgResults"`
+	Clicked        int64     `json:"clicked" bson:"clicked"` // Searches with at least one click
+	ClickThrough   float64   `json:"clickThroughRate" bson:"-"`
+	LastSearchedAt time.Time `json:"lastSearchedAt" bson:"lastSearchedAt"`
+}
+
+// SearchTotals counts searches and clicks over a period
+type SearchTotals struct {
+	Searches    int64 `json:"searches" bson:"searches"`
+	Clicked     int64 `json:"clicked" bson:"clicked"`
+	ZeroResults int64 `json:"zeroResults" bson:"zeroResults"`
+}
+
+// CreateIndexes supports the date range reports and drops searches older
+// than retention
+func (r *SearchLogRepository) CreateIndexes(ctx context.Context, retention time.Duration) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "createdAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(retention / time.Second)),
+		},
+		{Keys: bson.D{{Key: "term", Value: 1}, {Key: "createdAt", Value: -1}}},
+	})
+	return err
+}
+
+func (r *SearchLogRepository) Create(ctx context.Context, entry *models.SearchLog) error {
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+// RecordClick counts a click on a search result, keeping the first clicked
+// product
+func (r *SearchLogRepository) RecordClick(ctx context.Context, id, productID primitive.ObjectID) error {
+	now := time.Now()
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"clicks":           bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$clicks", 0}}, 1}},
+				"clickedProductId": bson.M{"$ifNull": bson.A{"$clickedProductId", productID}},
+				"clickedAt":        bson.M{"$ifNull": bson.A{"$clickedAt", now}},
+			}}},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func dateRange(from, to time.Time) bson.M {
+	return bson.M{"createdAt": bson.M{"$gte": from, "$lt": to}}
+}
+
+// TopQueries returns the most searched terms in [from, to)
+func (r *SearchLogRepository) TopQueries(ctx context.Context, from, to time.Time, limit int64) ([]QueryStat, error) {
+	return r.queryStats(ctx, dateRange(from, to), limit)
+}
+
+// ZeroResultQueries returns the most searched terms in [from, to) that found
+// nothing
+func (r *SearchLogRepository) ZeroResultQueries(ctx context.Context, from, to time.Time, limit int64) ([]QueryStat, error) {
+	match := dateRange(from, to)
+	match["resultCount"] = 0
+	return r.queryStats(ctx, match, limit)
+}
+
+func (r *SearchLogRepository) queryStats(ctx context.Context, match bson.M, limit int64) ([]QueryStat, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":            "$term",
+			"searches":       bson.M{"$sum": 1},
+			"avgResults":     bson.M{"$avg": "$resultCount"},
+			"clicked":        bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$clicks", 0}}, 1, 0}}},
+			"lastSearchedAt": bson.M{"$max": "$createdAt"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "searches", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	stats := []QueryStat{}
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// Totals counts the searches in [from, to), those with a click and those
+// with no results
+func (r *SearchLogRepository) Totals(ctx context.Context, from, to time.Time) (*SearchTotals, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: dateRange(from, to)}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         nil,
+			"searches":    bson.M{"$sum": 1},
+			"clicked":     bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$clicks", 0}}, 1, 0}}},
+			"zeroResults": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$resultCount", 0}}, 1, 0}}},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	totals := &SearchTotals{}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(totals); err != nil {
+			return nil, err
+		}
+	}
+	return totals, cursor.Err()
+}
@@ -0,0 +1,146 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxPreviousHashes is how many rotated refresh tokens a session remembers
+// for reuse detection. A leaked token is normally replayed within a few
+// refreshes; older ones simply stop matching any session.
+const maxPreviousHashes = 50
+
+type SessionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSessionRepository(db *mongo.Database) *SessionRepository {
+	return &SessionRepository{
+		collection: db.Collection("sessions"),
+	}
+}
+
+// CreateIndexes creates lookup indexes and expires sessions once their refresh token lapses
+func (r *SessionRepository) CreateIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}},
+		{Keys: bson.D{{Key: "previousHashes", Value: 1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session) error {
+	session.CreatedAt = time.Now()
+	session.LastUsedAt = session.CreatedAt
+	result, err := r.collection.InsertOne(ctx, session)
+	if err != nil {
+		return err
+	}
+	session.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// FindByTokenHash finds the session a refresh token belongs to, whether it is
+// the current token or one that has already been rotated
+func (r *SessionRepository) FindByTokenHash(ctx context.Context, hash string) (*models.Session, error) {
+	filter := bson.M{"$or": []bson.M{
+		{"tokenHash": hash},
+		{"previousHashes": hash},
+	}}
+
+	var session models.Session
+	if err := r.collection.FindOne(ctx, filter).Decode(&session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Rotate swaps the session's refresh token, but only if oldHash is still the
+// current one and the session is live. It reports whether the swap happened.
+func (r *SessionRepository) Rotate(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	filter := bson.M{
+		"_id":       id,
+		"tokenHash": oldHash,
+		"revokedAt": bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"tokenHash":  newHash,
+			"expiresAt":  expiresAt,
+			"lastUsedAt": time.Now(),
+		},
+		"$push": bson.M{"previousHashes": bson.M{
+			"$each":  bson.A{oldHash},
+			"$slice": -maxPreviousHashes,
+		}},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// Revoke ends a single session
+func (r *SessionRepository) Revoke(ctx context.Context, id primitive.ObjectID, reason string) error {
+	filter := bson.M{"_id": id, "revokedAt": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"revokedAt": time.Now(), "revokedReason": reason}}
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// RevokeAllForUser ends every live session of a user except keep (which may
+// be NilObjectID) and returns the IDs of the sessions it revoked
+func (r *SessionRepository) RevokeAllForUser(ctx context.Context, userID, keep primitive.ObjectID, reason string) ([]primitive.ObjectID, error) {
+	filter := bson.M{
+		"userId":    userID,
+		"_id":       bson.M{"$ne": keep},
+		"revokedAt": bson.M{"$exists": false},
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(docs))
+	for i, d := range docs {
+		ids[i] = d.ID
+	}
+
+	update := bson.M{"$set": bson.M{"revokedAt": time.Now(), "revokedReason": reason}}
+	_, err = r.collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}, "revokedAt": bson.M{"$exists": false}}, update)
+	return ids, err
+}
+
+// IsActive reports whether a session exists, hasn't expired and hasn't been revoked
+func (r *SessionRepository) IsActive(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"_id":       id,
+		"revokedAt": bson.M{"$exists": false},
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	return count == 1, err
+}
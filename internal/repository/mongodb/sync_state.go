@@ -0,0 +1,100 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrLeaseLost is returned when another instance has taken over a consumer
+var ErrLeaseLost = errors.New("sync lease held by another instance")
+
+// SyncStateRepository persists change stream positions, one document per
+// consumer. Each document also holds a lease, so only one instance runs a
+// consumer at a time.
+type SyncStateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSyncStateRepository(db *mongo.Database) *SyncStateRepository {
+	return &SyncStateRepository{
+		collection: db.Collection("sync_state"),
+	}
+}
+
+// Get returns the consumer's saved state, or nil if it has none yet
+func (r *SyncStateRepository) Get(ctx context.Context, name string) (*models.SyncState, error) {
+	var state models.SyncState
+	err := r.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&state)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Acquire takes or renews the consumer's lease for owner until ttl from now.
+// It reports false while another owner's lease is still running.
+func (r *SyncStateRepository) Acquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id": name,
+		"$or": []bson.M{
+			{"owner": owner},
+			{"leaseUntil": bson.M{"$exists": false}},
+			{"leaseUntil": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"owner": owner, "leaseUntil": now.Add(ttl)}}
+
+	// With the lease held elsewhere the filter matches nothing and the upsert
+	// collides with the existing document
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Release gives up owner's lease, so another instance can take over at once
+func (r *SyncStateRepository) Release(ctx context.Context, name, owner string) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": name, "owner": owner},
+		bson.M{"$unset": bson.M{"leaseUntil": ""}},
+	)
+	return err
+}
+
+// Save records the resume token after an applied event. It fails with
+// ErrLeaseLost unless owner holds the lease.
+func (r *SyncStateRepository) Save(ctx context.Context, name, owner string, token bson.Raw, lastEventAt time.Time) error {
+	return r.update(ctx, name, owner,
+		bson.M{"$set": bson.M{"resumeToken": token, "lastEventAt": lastEventAt, "updatedAt": time.Now()}},
+	)
+}
+
+// Reset forgets the resume token, so the consumer starts from the present. It
+// fails with ErrLeaseLost unless owner holds the lease.
+func (r *SyncStateRepository) Reset(ctx context.Context, name, owner string) error {
+	return r.update(ctx, name, owner,
+		bson.M{"$unset": bson.M{"resumeToken": ""}, "$set": bson.M{"updatedAt": time.Now()}},
+	)
+}
+
+func (r *SyncStateRepository) update(ctx context.Context, name, owner string, update bson.M) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": name, "owner": owner}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SynonymRepository stores the managed search synonym list. It is the source
+// of truth; the Elasticsearch synonyms set is rebuilt from it.
+type SynonymRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSynonymRepository(db *mongo.Database) *SynonymRepository {
+	return &SynonymRepository{
+		collection: db.Collection("search_synonyms"),
+	}
+}
+
+func (r *SynonymRepository) FindAll(ctx context.Context) ([]models.SynonymGroup, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	groups := []models.SynonymGroup{}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r *SynonymRepository) Count(ctx context.Context) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{})
+}
+
+func (r *SynonymRepository) Create(ctx context.Context, group *models.SynonymGroup) error {
+	group.CreatedAt = time.Now()
+	group.UpdatedAt = group.CreatedAt
+	result, err := r.collection.InsertOne(ctx, group)
+	if err != nil {
+		return err
+	}
+	group.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// UpdateTerms replaces a group's terms and returns the updated group
+func (r *SynonymRepository) UpdateTerms(ctx context.Context, id primitive.ObjectID, terms []string) (*models.SynonymGroup, error) {
+	var group models.SynonymGroup
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"terms": terms, "updatedAt": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&group)
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *SynonymRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
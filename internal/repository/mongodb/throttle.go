@@ -0,0 +1,77 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ThrottleRepository keeps fixed-window counters for rate limits and lockouts.
+// Counters live in MongoDB rather than Redis so a cache outage can't reset them.
+type ThrottleRepository struct {
+	collection *mongo.Collection
+}
+
+type throttleCounter struct {
+	Key       string    `bson:"_id"`
+	Count     int       `bson:"count"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+func NewThrottleRepository(db *mongo.Database) *ThrottleRepository {
+	return &ThrottleRepository{
+		collection: db.Collection("auth_throttles"),
+	}
+}
+
+// CreateIndexes removes counters once their window has passed
+func (r *ThrottleRepository) CreateIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Hit increments the counter for key and returns the new count and when the
+// window ends. A counter whose window has passed starts again at 1, even if
+// the TTL monitor hasn't removed it yet.
+func (r *ThrottleRepository) Hit(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	now := time.Now()
+	live := bson.M{"$gt": bson.A{"$expiresAt", now}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"count":     bson.M{"$cond": bson.A{live, bson.M{"$add": bson.A{"$count", 1}}, 1}},
+			"expiresAt": bson.M{"$cond": bson.A{live, "$expiresAt", now.Add(window)}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var counter throttleCounter
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&counter); err != nil {
+		return 0, time.Time{}, err
+	}
+	return counter.Count, counter.ExpiresAt, nil
+}
+
+// Get returns the current count for key, or zero if its window has passed
+func (r *ThrottleRepository) Get(ctx context.Context, key string) (int, time.Time, error) {
+	var counter throttleCounter
+	err := r.collection.FindOne(ctx, bson.M{"_id": key, "expiresAt": bson.M{"$gt": time.Now()}}).Decode(&counter)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, time.Time{}, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return counter.Count, counter.ExpiresAt, nil
+}
+
+func (r *ThrottleRepository) Reset(ctx context.Context, key string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
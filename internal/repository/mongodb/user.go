@@ -8,6 +8,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserRepository struct {
@@ -20,6 +21,15 @@ func NewUserRepository(db *mongo.Database) *UserRepository {
 	}
 }
 
+// CreateIndexes enforces one account per email, which FindByEmail relies on
+func (r *UserRepository) CreateIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
@@ -58,3 +68,79 @@ func (r *UserRepository) UpdateVerification(ctx context.Context, email string) e
 	)
 	return err
 }
+
+// UpdatePassword replaces the user's password hash
+func (r *UserRepository) UpdatePassword(ctx context.Context, id primitive.ObjectID, passwordHash string) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set": bson.M{
+				"passwordHash": passwordHash,
+				"updatedAt":    time.Now(),
+			},
+		},
+	)
+	return err
+}
+
+// UpdateProfile sets the given profile fields
+func (r *UserRepository) UpdateProfile(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	fields["updatedAt"] = time.Now()
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetAddresses replaces the user's address book
+func (r *UserRepository) SetAddresses(ctx context.Context, id primitive.ObjectID, addresses []models.SavedAddress) error {
+	return r.UpdateProfile(ctx, id, bson.M{"addresses": addresses})
+}
+
+// ChangeEmail swaps in the user's pending email. It returns ErrNoDocuments if
+// the pending email has changed since it was verified, and a duplicate key
+// error if another account took the address in the meantime.
+func (r *UserRepository) ChangeEmail(ctx context.Context, id primitive.ObjectID, newEmail string) error {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "pendingEmail": newEmail},
+		bson.M{
+			"$set":   bson.M{"email": newEmail, "updatedAt": time.Now()},
+			"$unset": bson.M{"pendingEmail": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// AddRole grants a role to the user with the given email
+func (r *UserRepository) AddRole(ctx context.Context, email, role string) error {
+	return r.updateRoles(ctx, email, bson.M{"$addToSet": bson.M{"roles": role}})
+}
+
+// RemoveRole revokes a role from the user with the given email
+func (r *UserRepository) RemoveRole(ctx context.Context, email, role string) error {
+	return r.updateRoles(ctx, email, bson.M{"$pull": bson.M{"roles": role}})
+}
+
+func (r *UserRepository) updateRoles(ctx context.Context, email string, update bson.M) error {
+	update["$set"] = bson.M{"updatedAt": time.Now()}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"email": email}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -3,101 +3,200 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/khusa-mahal/backend/internal/cache"
 	"github.com/khusa-mahal/backend/internal/config"
 	"github.com/khusa-mahal/backend/internal/models"
 	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 )
 
+// Cache stores products and listings in the store picked by CACHE_MODE, and
+// carts and session revocations, which every instance must agree on, in Redis
+// only. Memory mode runs a single instance, so it keeps those in process too.
 type Cache struct {
-	client *redis.Client
+	client *redis.Client  // nil in memory mode
+	remote *cache.Breaker // Redis behind its circuit breaker; nil in memory mode
+	local  *cache.LRU     // In-process tier; nil in redis mode
+	store  cache.Store    // Products and listings
+	shared cache.Store    // Carts and session revocations
 	config *config.CacheConfig
+
+	flight     singleflight.Group // Coalesces loads of the same key
+	refreshing sync.Map           // Keys being refreshed in the background
+	missed     atomic.Bool        // A generation bump didn't reach the store
 }
 
 func NewCache(cfg *config.Config) *Cache {
-	client := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", cfg.Redis.Host, cfg.Redis.Port),
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
-	})
+	c := &Cache{config: &cfg.Cache}
+
+	if cfg.Cache.Mode != cache.ModeMemory {
+		c.client = redis.NewClient(&redis.Options{
+			Addr:     fmt.Sprintf("%s:%s", cfg.Redis.Host, cfg.Redis.Port),
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		c.remote = cache.NewBreaker("Redis", &store{client: c.client}, cfg.Cache.BreakerThreshold, cfg.Cache.BreakerCooldown)
+		c.remote.OnRecover(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+			defer cancel()
+			c.retireMissed(ctx)
+		})
+	}
 
-	return &Cache{
-		client: client,
-		config: &cfg.Cache,
+	switch cfg.Cache.Mode {
+	case cache.ModeMemory:
+		c.local = cache.NewLRU(cfg.Cache.LocalMaxEntries)
+		c.store = c.local
+		// Kept apart so products can't push revocations out
+		c.shared = cache.NewLRU(cfg.Cache.LocalMaxEntries)
+	case cache.ModeTiered:
+		c.local = cache.NewLRU(cfg.Cache.LocalMaxEntries)
+		c.store = &cache.Tiered{L1: c.local, L2: c.remote, L1TTL: cfg.Cache.LocalTTL}
+		c.shared = c.remote
+	default:
+		c.store = c.remote
+		c.shared = c.remote
 	}
+
+	return c
 }
 
-// Product cache operations
+// Mode returns the configured cache mode
+func (c *Cache) Mode() string {
+	return c.config.Mode
+}
 
-func (c *Cache) GetProduct(ctx context.Context, id string) (*models.Product, error) {
-	key := fmt.Sprintf("product:%s", id)
-	val, err := c.client.Get(ctx, key).Result()
-	if err != nil {
-		return nil, err
-	}
+// Product cache operations
+//
+// Product keys embed generation counters, product:<id>:<products>.<product>.
+// A write bumps the product's counter rather than deleting the key, so a load
+// that read the product before the write can only store what it read under a
+// key that is no longer used.
 
-	var product models.Product
-	if err := json.Unmarshal([]byte(val), &product); err != nil {
-		return nil, err
-	}
+// Bumped when product writes may have been missed, retiring every product
+const genProducts = "products:gen:products"
 
-	return &product, nil
+func genProduct(id string) string {
+	return fmt.Sprintf("products:gen:product:%s", id)
 }
 
-func (c *Cache) SetProduct(ctx context.Context, id string, product *models.Product) error {
-	key := fmt.Sprintf("product:%s", id)
-	data, err := json.Marshal(product)
+// productKey returns the key of a product under the current generations
+func (c *Cache) productKey(ctx context.Context, id string) (string, error) {
+	gens, err := c.store.Counters(ctx, genProducts, genProduct(id))
 	if err != nil {
-		return err
+		return "", err
 	}
-
-	return c.client.Set(ctx, key, data, c.config.ProductTTL).Err()
+	return fmt.Sprintf("product:%s:%d.%d", id, gens[0], gens[1]), nil
 }
 
-func (c *Cache) DeleteProduct(ctx context.Context, id string) error {
-	key := fmt.Sprintf("product:%s", id)
-	return c.client.Del(ctx, key).Err()
+// FetchProduct returns the cached product, loading it on a miss. A nil product
+// means load reported it doesn't exist. The bool reports a cache hit.
+func (c *Cache) FetchProduct(ctx context.Context, id string, load Loader[models.Product]) (*models.Product, bool, error) {
+	key, err := c.productKey(ctx, id)
+	return fetchVersioned(ctx, c, key, err, c.config.ProductTTL, load)
 }
 
 // Product list cache operations
+//
+// Listing keys embed generation counters: products:list:all:<all>:<filter>
+// for unfiltered listings and products:list:<slug>:<categories>.<category>:<filter>
+// for a category's. Listings filtered on stock add .<stock> to the counters.
+// Bumping a counter retires every listing built on it at once; the old keys
+// are never read again and simply expire.
+
+const (
+	// Bumped on every product change but stock changes
+	genAll = "products:gen:all"
+	// Bumped when the categories a change touched aren't known
+	genCategories = "products:gen:categories"
+	// Bumped when some stock reaches or leaves zero
+	genStock = "products:gen:stock"
+)
 
-func (c *Cache) GetProductList(ctx context.Context, filter string) ([]models.Product, error) {
-	key := fmt.Sprintf("products:list:%s", filter)
-	val, err := c.client.Get(ctx, key).Result()
+func genCategory(slug string) string {
+	return fmt.Sprintf("products:gen:category:%s", slug)
+}
+
+// listKey returns the key of a listing under the current generations. slug
+// is empty for listings not filtered by category, and inStock reports a
+// listing filtered on stock.
+func (c *Cache) listKey(ctx context.Context, slug, filter string, inStock bool) (string, error) {
+	names := []string{genAll}
+	prefix := "products:list:all"
+	if slug != "" {
+		names = []string{genCategories, genCategory(slug)}
+		prefix = "products:list:" + slug
+	}
+	if inStock {
+		names = append(names, genStock)
+	}
+
+	gens, err := c.store.Counters(ctx, names...)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	versions := make([]string, len(gens))
+	for i, gen := range gens {
+		versions[i] = strconv.FormatInt(gen, 10)
 	}
+	return fmt.Sprintf("%s:%s:%s", prefix, strings.Join(versions, "."), filter), nil
+}
 
-	var products []models.Product
-	if err := json.Unmarshal([]byte(val), &products); err != nil {
-		return nil, err
+// fetchVersioned is fetch for keys built from generation counters. keyErr is
+// the error reading the generations; without them the key can't be built, so
+// the value is loaded uncached.
+func fetchVersioned[T any](ctx context.Context, c *Cache, key string, keyErr error, ttl time.Duration, load Loader[T]) (*T, bool, error) {
+	if keyErr != nil {
+		value, err := load(ctx)
+		return value, false, err
 	}
+	return fetch(ctx, c, key, ttl, load)
+}
 
-	return products, nil
+// fetchList is fetch for listings
+func fetchList[T any](ctx context.Context, c *Cache, slug, filter string, inStock bool, load Loader[T]) (*T, bool, error) {
+	key, err := c.listKey(ctx, slug, filter, inStock)
+	return fetchVersioned(ctx, c, key, err, c.config.ListTTL, load)
 }
 
-func (c *Cache) SetProductList(ctx context.Context, filter string, products []models.Product) error {
-	key := fmt.Sprintf("products:list:%s", filter)
-	data, err := json.Marshal(products)
-	if err != nil {
-		return err
-	}
+// FetchProductPage returns a cached page of a product listing, loading it on
+// a miss. slug is the category filter's slug, empty for none, filter the
+// listing's normalized cache key and inStock whether it is filtered on stock.
+// The bool reports a cache hit.
+func (c *Cache) FetchProductPage(ctx context.Context, slug, filter string, inStock bool, load Loader[models.ProductPage]) (*models.ProductPage, bool, error) {
+	return fetchList(ctx, c, slug, filter, inStock, load)
+}
 
-	return c.client.Set(ctx, key, data, c.config.ListTTL).Err()
+// FetchCategoryProducts returns the cached product list of a category page,
+// loading it on a miss. The bool reports a cache hit.
+func (c *Cache) FetchCategoryProducts(ctx context.Context, slug string, load Loader[[]models.Product]) ([]models.Product, bool, error) {
+	products, cached, err := fetchList(ctx, c, slug, "category", false, load)
+	if err != nil || products == nil {
+		return nil, cached, err
+	}
+	return *products, cached, nil
 }
 
 // Cart cache operations
 
 func (c *Cache) GetCart(ctx context.Context, sessionID string) (*models.Cart, error) {
 	key := fmt.Sprintf("cart:%s", sessionID)
-	val, err := c.client.Get(ctx, key).Result()
+	val, err := c.shared.Get(ctx, key)
 	if err != nil {
 		return nil, err
 	}
 
 	var cart models.Cart
-	if err := json.Unmarshal([]byte(val), &cart); err != nil {
+	if err := json.Unmarshal(val, &cart); err != nil {
 		return nil, err
 	}
 
@@ -111,25 +210,25 @@ func (c *Cache) SetCart(ctx context.Context, sessionID string, cart *models.Cart
 		return err
 	}
 
-	return c.client.Set(ctx, key, data, c.config.CartTTL).Err()
+	return c.shared.Set(ctx, key, data, c.config.CartTTL)
 }
 
 func (c *Cache) DeleteCart(ctx context.Context, sessionID string) error {
 	key := fmt.Sprintf("cart:%s", sessionID)
-	return c.client.Del(ctx, key).Err()
+	return c.shared.Delete(ctx, key)
 }
 
 // User cart operations
 
 func (c *Cache) GetUserCart(ctx context.Context, userID string) (*models.Cart, error) {
 	key := fmt.Sprintf("cart:user:%s", userID)
-	val, err := c.client.Get(ctx, key).Result()
+	val, err := c.shared.Get(ctx, key)
 	if err != nil {
 		return nil, err
 	}
 
 	var cart models.Cart
-	if err := json.Unmarshal([]byte(val), &cart); err != nil {
+	if err := json.Unmarshal(val, &cart); err != nil {
 		return nil, err
 	}
 
@@ -144,26 +243,118 @@ func (c *Cache) SetUserCart(ctx context.Context, userID string, cart *models.Car
 	}
 
 	// User carts don't expire
-	return c.client.Set(ctx, key, data, 0).Err()
+	return c.shared.Set(ctx, key, data, 0)
+}
+
+// Session revocation
+
+// RevokeSession marks a session as revoked for ttl, which should cover the
+// lifetime of any access token already issued for it
+func (c *Cache) RevokeSession(ctx context.Context, sessionID string, ttl time.Duration) error {
+	key := fmt.Sprintf("auth:revoked:%s", sessionID)
+	return c.shared.Set(ctx, key, []byte("1"), ttl)
+}
+
+// IsSessionRevoked reports whether RevokeSession was called for the session
+func (c *Cache) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	key := fmt.Sprintf("auth:revoked:%s", sessionID)
+	_, err := c.shared.Get(ctx, key)
+	if errors.Is(err, cache.ErrMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
-// Invalidate all product caches (useful after updates)
-func (c *Cache) InvalidateProductCaches(ctx context.Context) error {
-	iter := c.client.Scan(ctx, 0, "products:list:*", 0).Iterator()
-	for iter.Next(ctx) {
-		if err := c.client.Del(ctx, iter.Val()).Err(); err != nil {
+// EvictProducts retires the cached copies of changed products and the
+// listings that may include them: every unfiltered listing, the listings
+// filtered by the changed categories' names and their category pages, or
+// every category's when the categories aren't known. A stock-only change
+// retires just the listings filtered on stock, and only when some stock
+// reached or left zero; the rest show its stock counts until their TTL. It
+// costs one write per counter whatever the number of cached listings.
+func (c *Cache) EvictProducts(ctx context.Context, change models.ProductChange) error {
+	c.retireMissed(ctx)
+
+	var gens []string
+	for _, id := range change.IDs {
+		gens = append(gens, genProduct(id))
+	}
+	if change.StockOnly {
+		if change.StockFlipped {
+			gens = append(gens, genStock)
+		}
+	} else {
+		gens = append(gens, genAll)
+		if change.Categories == nil && change.CategorySlugs == nil {
+			gens = append(gens, genCategories)
+		}
+		slugs := slices.Clone(change.CategorySlugs)
+		for _, category := range change.Categories {
+			slugs = append(slugs, models.Slugify(category))
+		}
+		for _, slug := range slugs {
+			if gen := genCategory(slug); !slices.Contains(gens, gen) {
+				gens = append(gens, gen)
+			}
+		}
+	}
+
+	for _, gen := range gens {
+		if _, err := c.store.Incr(ctx, gen); err != nil {
+			c.missed.Store(true)
 			return err
 		}
 	}
-	return iter.Err()
+	return nil
+}
+
+// retireMissed retires every cached product and listing once generation
+// bumps have failed, as they do while Redis is unreachable. Which products
+// changed isn't known, so all of them are treated as changed. It runs when
+// the breaker closes and before the next eviction.
+func (c *Cache) retireMissed(ctx context.Context) {
+	// While the breaker is open this would only fail again
+	if (c.remote != nil && c.remote.Open()) || !c.missed.Swap(false) {
+		return
+	}
+
+	for _, gen := range []string{genProducts, genAll, genCategories} {
+		if _, err := c.store.Incr(ctx, gen); err != nil {
+			c.missed.Store(true)
+			fmt.Printf("⚠️  Failed to retire cached products after missed invalidations: %v\n", err)
+			return
+		}
+	}
+	fmt.Println("✅ Retired cached products and listings after missed invalidations")
 }
 
-// Ping checks Redis connection
+// publish sends a message to the other instances. It is skipped in memory
+// mode and while Redis is known to be down.
+func (c *Cache) publish(ctx context.Context, channel string, data []byte) error {
+	if c.remote == nil {
+		return nil
+	}
+	if c.remote.Open() {
+		return cache.ErrUnavailable
+	}
+	return c.client.Publish(ctx, channel, data).Err()
+}
+
+// Ping checks the cache can be reached: Redis, except in memory mode
 func (c *Cache) Ping(ctx context.Context) error {
-	return c.client.Ping(ctx).Err()
+	if c.remote == nil {
+		return nil
+	}
+	return c.remote.Ping(ctx)
 }
 
 // Close closes the Redis client
 func (c *Cache) Close() error {
+	if c.client == nil {
+		return nil
+	}
 	return c.client.Close()
 }
@@ -0,0 +1,146 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// loadTimeout bounds a load shared by the requests missing one key
+	loadTimeout = 10 * time.Second
+	// refreshTimeout bounds a background refresh of a stale entry
+	refreshTimeout = 10 * time.Second
+)
+
+// Loader loads a value missing from the cache. Returning nil with no error
+// means the value doesn't exist, which is cached for the negative TTL.
+type Loader[T any] func(ctx context.Context) (*T, error)
+
+// entry is the stored form of a fetched value. Redis keeps the key for the
+// stale TTL beyond FreshUntil, and in between the old value is still served
+// while a single request loads a new one.
+type entry struct {
+	FreshUntil time.Time       `json:"freshUntil"`
+	Missing    bool            `json:"missing,omitempty"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+// fetch returns the value cached under key, loading and caching it on a miss.
+// Concurrent misses for one key share a single load, and an entry older than
+// ttl is returned as is while one background load refreshes it. A nil value
+// means the loader reported it missing. The bool reports whether the value
+// came from the cache.
+func fetch[T any](ctx context.Context, c *Cache, key string, ttl time.Duration, load Loader[T]) (*T, bool, error) {
+	loadRaw := func(ctx context.Context) (json.RawMessage, error) {
+		value, err := load(ctx)
+		if err != nil || value == nil {
+			return nil, err
+		}
+		return json.Marshal(value)
+	}
+
+	if e := c.lookup(ctx, key); e != nil {
+		if time.Now().After(e.FreshUntil) {
+			c.refresh(key, ttl, loadRaw)
+		}
+		if value, err := decodeEntry[T](e); err == nil {
+			return value, true, nil
+		}
+	}
+
+	// The load is shared, so it mustn't fail for everyone when the request
+	// that started it goes away. Each caller still stops waiting when its own
+	// context is done.
+	loaded := c.flight.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+		defer cancel()
+		return c.fill(ctx, key, ttl, loadRaw)
+	})
+
+	var result singleflight.Result
+	select {
+	case result = <-loaded:
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+	if result.Err != nil {
+		return nil, false, result.Err
+	}
+
+	value, err := decodeEntry[T](result.Val.(*entry))
+	return value, false, err
+}
+
+func decodeEntry[T any](e *entry) (*T, error) {
+	if e.Missing {
+		return nil, nil
+	}
+
+	var value T
+	if err := json.Unmarshal(e.Data, &value); err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// lookup returns the entry stored under key, or nil on a miss
+func (c *Cache) lookup(ctx context.Context, key string) *entry {
+	data, err := c.store.Get(ctx, key)
+	if err != nil {
+		return nil
+	}
+
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil || (!e.Missing && e.Data == nil) {
+		return nil
+	}
+	return &e
+}
+
+// fill loads the value for key and stores it. Failing to store it is not an
+// error; the next request simply loads it again.
+func (c *Cache) fill(ctx context.Context, key string, ttl time.Duration, load func(context.Context) (json.RawMessage, error)) (*entry, error) {
+	data, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &entry{FreshUntil: time.Now().Add(ttl), Data: data}
+	expiry := ttl + c.config.StaleTTL
+	if data == nil {
+		e.Missing = true
+		e.FreshUntil = time.Now().Add(c.config.NegativeTTL)
+		expiry = c.config.NegativeTTL
+	}
+
+	if stored, err := json.Marshal(e); err == nil {
+		_ = c.store.Set(ctx, key, stored, expiry)
+	}
+	return e, nil
+}
+
+// refresh reloads a stale entry in the background, at most once at a time
+// per key
+func (c *Cache) refresh(key string, ttl time.Duration, load func(context.Context) (json.RawMessage, error)) {
+	if _, busy := c.refreshing.LoadOrStore(key, struct{}{}); busy {
+		return
+	}
+
+	go func() {
+		defer c.refreshing.Delete(key)
+
+		ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+		defer cancel()
+
+		_, err, _ := c.flight.Do(key, func() (interface{}, error) {
+			return c.fill(ctx, key, ttl, load)
+		})
+		if err != nil {
+			fmt.Printf("⚠️  Failed to refresh cached %s: %v\n", key, err)
+		}
+	}()
+}
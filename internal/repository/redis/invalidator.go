@@ -0,0 +1,115 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/khusa-mahal/backend/internal/models"
+)
+
+// invalidationChannel carries product changes between server instances
+const invalidationChannel = "cache:invalidate"
+
+type invalidationMessage struct {
+	Origin string `json:"origin"`
+	models.ProductChange
+}
+
+// Invalidator keeps caches in step with product writes. It is registered as
+// the product repository's listener: each write bumps the generations in
+// Redis, which every instance reads its cache keys from, and is published on
+// a pub/sub channel so every instance, this one included, can update other
+// copies held in process memory.
+type Invalidator struct {
+	cache      *Cache
+	instanceID string
+
+	mu       sync.RWMutex
+	handlers []func(ctx context.Context, change models.ProductChange)
+}
+
+func NewInvalidator(cache *Cache) *Invalidator {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+
+	return &Invalidator{
+		cache:      cache,
+		instanceID: hex.EncodeToString(id),
+	}
+}
+
+// OnChange registers a handler for local copies of product data. It runs for
+// writes made by this instance and, once Listen is running, by the others.
+func (i *Invalidator) OnChange(handler func(ctx context.Context, change models.ProductChange)) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.handlers = append(i.handlers, handler)
+}
+
+// ProductsChanged retires the cached copies of the changed products and tells
+// the other instances about them. Failures are logged rather than returned: the write
+// has already happened, and the TTLs bound how long a stale copy survives.
+func (i *Invalidator) ProductsChanged(ctx context.Context, change models.ProductChange) {
+	if err := i.cache.EvictProducts(ctx, change); err != nil {
+		fmt.Printf("⚠️  Failed to evict cached products %v: %v\n", change.IDs, err)
+	}
+
+	data, err := json.Marshal(invalidationMessage{Origin: i.instanceID, ProductChange: change})
+	if err == nil {
+		err = i.cache.publish(ctx, invalidationChannel, data)
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Failed to publish invalidation for products %v: %v\n", change.IDs, err)
+	}
+
+	i.dispatch(ctx, change)
+}
+
+// Listen applies invalidations published by other instances until ctx is
+// cancelled. The publisher has already bumped the generations in Redis, so
+// only the local handlers run. In memory mode there are no other instances to
+// hear from.
+func (i *Invalidator) Listen(ctx context.Context) {
+	if i.cache.client == nil {
+		return
+	}
+
+	sub := i.cache.client.Subscribe(ctx, invalidationChannel)
+	defer sub.Close()
+	messages := sub.Channel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			var message invalidationMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+				fmt.Printf("⚠️  Ignoring malformed invalidation message: %v\n", err)
+				continue
+			}
+			if message.Origin == i.instanceID {
+				continue
+			}
+			i.dispatch(ctx, message.ProductChange)
+		}
+	}
+}
+
+func (i *Invalidator) dispatch(ctx context.Context, change models.ProductChange) {
+	i.mu.RLock()
+	handlers := i.handlers
+	i.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(ctx, change)
+	}
+}
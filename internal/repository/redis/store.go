@@ -0,0 +1,60 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/khusa-mahal/backend/internal/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// store is the Redis implementation of cache.Store
+type store struct {
+	client *redis.Client
+}
+
+func (s *store) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, cache.ErrMiss
+	}
+	return value, err
+}
+
+func (s *store) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (s *store) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return s.client.Del(ctx, keys...).Err()
+}
+
+func (s *store) Incr(ctx context.Context, key string) (int64, error) {
+	return s.client.Incr(ctx, key).Result()
+}
+
+func (s *store) Counters(ctx context.Context, keys ...string) ([]int64, error) {
+	raw, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]int64, len(keys))
+	for i, v := range raw {
+		if text, ok := v.(string); ok {
+			if values[i], err = strconv.ParseInt(text, 10, 64); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return values, nil
+}
+
+func (s *store) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
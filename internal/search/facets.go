@@ -0,0 +1,146 @@
+package search
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/khusa-mahal/backend/internal/models"
+)
+
+// facetMatchers returns a predicate for every facet the query restricts
+func facetMatchers(q models.SearchQuery) map[string]func(*models.Product) bool {
+	matchers := map[string]func(*models.Product) bool{}
+
+	if q.Category != "" {
+		matchers["categories"] = func(p *models.Product) bool {
+			return strings.EqualFold(p.Category, q.Category)
+		}
+	}
+	if len(q.Sizes) > 0 {
+		matchers["sizes"] = func(p *models.Product) bool {
+			for _, size := range p.Sizes {
+				if containsFold(q.Sizes, size) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	if len(q.Colors) > 0 {
+		matchers["colors"] = func(p *models.Product) bool {
+			for _, color := range p.Colors {
+				if containsFold(q.Colors, color.Name) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	if q.MinPrice != nil || q.MaxPrice != nil {
+		matchers["prices"] = func(p *models.Product) bool {
+			return (q.MinPrice == nil || p.Price >= *q.MinPrice) && (q.MaxPrice == nil || p.Price <= *q.MaxPrice)
+		}
+	}
+	if q.IsSale != nil {
+		matchers["sale"] = func(p *models.Product) bool {
+			return p.IsSale == *q.IsSale
+		}
+	}
+
+	return matchers
+}
+
+// facetProducts filters, counts and paginates search hits the same way the
+// Elasticsearch query does: each facet is counted without its own filter
+func facetProducts(products []models.Product, q models.SearchQuery) *models.SearchResult {
+	matchers := facetMatchers(q)
+	matchesExcept := func(p *models.Product, except string) bool {
+		for name, match := range matchers {
+			if name != except && !match(p) {
+				return false
+			}
+		}
+		return true
+	}
+
+	categories := map[string]int64{}
+	sizes := map[string]int64{}
+	colors := map[string]int64{}
+	prices := make([]models.PriceRange, len(models.PriceRanges))
+	copy(prices, models.PriceRanges)
+
+	result := &models.SearchResult{Products: []models.Product{}}
+	var hits []models.Product
+	for i := range products {
+		p := &products[i]
+		if matchesExcept(p, "") {
+			hits = append(hits, *p)
+		}
+		if matchesExcept(p, "categories") {
+			categories[p.Category]++
+		}
+		if matchesExcept(p, "sizes") {
+			for _, size := range p.Sizes {
+				sizes[size]++
+			}
+		}
+		if matchesExcept(p, "colors") {
+			for _, color := range p.Colors {
+				colors[color.Name]++
+			}
+		}
+		if matchesExcept(p, "prices") {
+			for j := range prices {
+				if prices[j].Contains(p.Price) {
+					prices[j].Count++
+				}
+			}
+		}
+		if matchesExcept(p, "sale") && p.IsSale {
+			result.Facets.OnSale++
+		}
+	}
+
+	result.Total = int64(len(hits))
+	result.Facets.Categories = facetBuckets(categories)
+	result.Facets.Sizes = facetBuckets(sizes)
+	result.Facets.Colors = facetBuckets(colors)
+	result.Facets.Prices = prices
+
+	start := (q.Page - 1) * q.Limit
+	if start < len(hits) {
+		end := start + q.Limit
+		if end > len(hits) {
+			end = len(hits)
+		}
+		result.Products = hits[start:end]
+	}
+	return result
+}
+
+// facetBuckets orders counts like an Elasticsearch terms aggregation: by
+// count, then by value
+func facetBuckets(counts map[string]int64) []models.FacetBucket {
+	buckets := make([]models.FacetBucket, 0, len(counts))
+	for value, count := range counts {
+		if value != "" {
+			buckets = append(buckets, models.FacetBucket{Value: value, Count: count})
+		}
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].Count != buckets[j].Count {
+			return buckets[i].Count > buckets[j].Count
+		}
+		return buckets[i].Value < buckets[j].Value
+	})
+	return buckets
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/khusa-mahal/backend/internal/models"
+)
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func boolPtr(v bool) *bool {
+	return &v
+}
+
+func TestFacetProductsFilters(t *testing.T) {
+	// Newest first, as the backends pass them
+	catalog := testCatalog()[:3]
+	catalog[0], catalog[2] = catalog[2], catalog[0]
+
+	tests := []struct {
+		name  string
+		query models.SearchQuery
+		want  []string
+	}{
+		{"no filters", models.SearchQuery{}, []string{"Kolhapuri Chappal", "Tilla Work Khussa", "Velvet Khussa"}},
+		{"category ignores case", models.SearchQuery{Category: "bridal"}, []string{"Tilla Work Khussa", "Velvet Khussa"}},
+		{"any of the sizes", models.SearchQuery{Sizes: []string{"9", "7"}}, []string{"Kolhapuri Chappal", "Velvet Khussa"}},
+		{"color ignores case", models.SearchQuery{Colors: []string{"gold"}}, []string{"Tilla Work Khussa", "Velvet Khussa"}},
+		{"price range", models.SearchQuery{MinPrice: floatPtr(2000), MaxPrice: floatPtr(5000)}, []string{"Velvet Khussa"}},
+		{"on sale", models.SearchQuery{IsSale: boolPtr(true)}, []string{"Velvet Khussa"}},
+		{"not on sale", models.SearchQuery{IsSale: boolPtr(false)}, []string{"Kolhapuri Chappal", "Tilla Work Khussa"}},
+		{"filters combine", models.SearchQuery{Category: "Bridal", Sizes: []string{"7"}}, []string{"Velvet Khussa"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.query.Page, tt.query.Limit = 1, 20
+			result := facetProducts(catalog, tt.query)
+			if got := productNames(result.Products); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("products = %v, want %v", got, tt.want)
+			}
+			if result.Total != int64(len(tt.want)) {
+				t.Errorf("Total = %d, want %d", result.Total, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestFacetProductsCountsEachFacetWithoutItsOwnFilter(t *testing.T) {
+	query := models.SearchQuery{Category: "Bridal", Sizes: []string{"7"}, Page: 1, Limit: 20}
+	result := facetProducts(testCatalog()[:3], query)
+
+	// Categories are counted with only the size filter: both size 7 products
+	wantCategories := []models.FacetBucket{{Value: "Bridal", Count: 1}, {Value: "Casual", Count: 1}}
+	if !reflect.DeepEqual(result.Facets.Categories, wantCategories) {
+		t.Errorf("categories = %v, want %v", result.Facets.Categories, wantCategories)
+	}
+	// Sizes are counted with only the category filter: both bridal products
+	wantSizes := []models.FacetBucket{{Value: "8", Count: 2}, {Value: "7", Count: 1}}
+	if !reflect.DeepEqual(result.Facets.Sizes, wantSizes) {
+		t.Errorf("sizes = %v, want %v", result.Facets.Sizes, wantSizes)
+	}
+	// Colors are counted with every filter
+	wantColors := []models.FacetBucket{{Value: "Gold", Count: 1}, {Value: "Maroon", Count: 1}}
+	if !reflect.DeepEqual(result.Facets.Colors, wantColors) {
+		t.Errorf("colors = %v, want %v", result.Facets.Colors, wantColors)
+	}
+	if result.Facets.OnSale != 1 {
+		t.Errorf("on sale = %d, want 1", result.Facets.OnSale)
+	}
+
+	prices := map[string]int64{}
+	for _, r := range result.Facets.Prices {
+		prices[r.Key] = r.Count
+	}
+	wantPrices := map[string]int64{"under-2000": 0, "2000-4000": 0, "4000-6000": 1, "6000-10000": 0, "10000-plus": 0}
+	if !reflect.DeepEqual(prices, wantPrices) {
+		t.Errorf("prices = %v, want %v", prices, wantPrices)
+	}
+	if models.PriceRanges[2].Count != 0 {
+		t.Error("facetProducts changed the shared price ranges")
+	}
+}
+
+func TestFacetProductsPaginates(t *testing.T) {
+	catalog := testCatalog()[:3]
+
+	tests := []struct {
+		page, limit int
+		want        []string
+	}{
+		{1, 2, []string{"Velvet Khussa", "Tilla Work Khussa"}},
+		{2, 2, []string{"Kolhapuri Chappal"}},
+		{3, 2, []string{}},
+	}
+	for _, tt := range tests {
+		result := facetProducts(catalog, models.SearchQuery{Page: tt.page, Limit: tt.limit})
+		if got := productNames(result.Products); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("page %d = %v, want %v", tt.page, got, tt.want)
+		}
+		if result.Total != 3 {
+			t.Errorf("page %d Total = %d, want 3", tt.page, result.Total)
+		}
+	}
+}
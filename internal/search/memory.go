@@ -0,0 +1,131 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/khusa-mahal/backend/internal/models"
+)
+
+// MemoryBackend keeps live products in a map and matches them by substring.
+// It needs no external service, which suits tests and local development;
+// it only knows the products given to Load and IndexProduct.
+type MemoryBackend struct {
+	mu       sync.RWMutex
+	products map[string]models.Product
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{products: map[string]models.Product{}}
+}
+
+func (b *MemoryBackend) Name() string {
+	return BackendMemory
+}
+
+// Load replaces the contents with the given live products
+func (b *MemoryBackend) Load(products []models.Product) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.products = make(map[string]models.Product, len(products))
+	for _, p := range products {
+		if !p.IsArchived {
+			b.products[p.ID.Hex()] = p
+		}
+	}
+}
+
+// snapshot returns the products in a stable order: newest first, then by ID
+func (b *MemoryBackend) snapshot() []models.Product {
+	b.mu.RLock()
+	products := make([]models.Product, 0, len(b.products))
+	for _, p := range b.products {
+		products = append(products, p)
+	}
+	b.mu.RUnlock()
+
+	sort.Slice(products, func(i, j int) bool {
+		if !products[i].CreatedAt.Equal(products[j].CreatedAt) {
+			return products[i].CreatedAt.After(products[j].CreatedAt)
+		}
+		return products[i].ID.Hex() < products[j].ID.Hex()
+	})
+	return products
+}
+
+// Search returns products containing every word of the query in their name,
+// Urdu name, category or description
+func (b *MemoryBackend) Search(ctx context.Context, q models.SearchQuery) (*models.SearchResult, error) {
+	words := strings.Fields(strings.ToLower(q.Text))
+
+	var hits []models.Product
+	for _, p := range b.snapshot() {
+		text := strings.ToLower(strings.Join([]string{p.Name, p.NameUrdu, p.Category, p.Description}, " "))
+		matched := true
+		for _, word := range words {
+			if !strings.Contains(text, word) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			hits = append(hits, p)
+		}
+	}
+	return facetProducts(hits, q), nil
+}
+
+// Suggest matches word prefixes of product names and categories
+func (b *MemoryBackend) Suggest(ctx context.Context, prefix string, size int) ([]models.Suggestion, error) {
+	prefix = strings.ToLower(prefix)
+
+	var products []models.Product
+	categories := []string{}
+	seen := map[string]bool{}
+	for _, p := range b.snapshot() {
+		if hasWordPrefix(p.Name, prefix) && len(products) < size {
+			products = append(products, p)
+		}
+		if !seen[p.Category] && hasWordPrefix(p.Category, prefix) {
+			seen[p.Category] = true
+			categories = append(categories, p.Category)
+		}
+	}
+	return buildSuggestions(products, categories, size), nil
+}
+
+// hasWordPrefix reports whether prefix occurs in text at the start of a word
+func hasWordPrefix(text, prefix string) bool {
+	text = strings.ToLower(text)
+	for i := 0; i < len(text); i++ {
+		j := strings.Index(text[i:], prefix)
+		if j < 0 {
+			return false
+		}
+		i += j
+		if i == 0 || text[i-1] == ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *MemoryBackend) IndexProduct(ctx context.Context, product *models.Product) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.products[product.ID.Hex()] = *product
+	return nil
+}
+
+func (b *MemoryBackend) DeleteProduct(ctx context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.products[id]; !ok {
+		return ErrNotFound
+	}
+	delete(b.products, id)
+	return nil
+}
@@ -0,0 +1,214 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var testEpoch = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+// testProduct returns a product whose ID and age follow n: higher n is newer
+func testProduct(n int, name, category string, price float64) models.Product {
+	var id primitive.ObjectID
+	id[len(id)-1] = byte(n)
+	return models.Product{
+		ID:        id,
+		Name:      name,
+		Category:  category,
+		Price:     price,
+		CreatedAt: testEpoch.Add(time.Duration(n) * time.Hour),
+	}
+}
+
+func testCatalog() []models.Product {
+	velvet := testProduct(1, "Velvet Khussa", "Bridal", 4500)
+	velvet.Sizes = []string{"7", "8"}
+	velvet.Colors = []models.ColorOption{{Name: "Gold"}, {Name: "Maroon"}}
+	velvet.IsSale = true
+
+	tilla := testProduct(2, "Tilla Work Khussa", "Bridal", 6500)
+	tilla.NameUrdu = "تلہ کھسہ"
+	tilla.Sizes = []string{"8"}
+	tilla.Colors = []models.ColorOption{{Name: "Gold"}}
+
+	kolhapuri := testProduct(3, "Kolhapuri Chappal", "Casual", 1800)
+	kolhapuri.Description = "Soft velvet lining"
+	kolhapuri.Sizes = []string{"7", "9"}
+	kolhapuri.Colors = []models.ColorOption{{Name: "Tan"}}
+
+	archived := testProduct(4, "Velvet Mule", "Casual", 2500)
+	archived.IsArchived = true
+
+	return []models.Product{velvet, tilla, kolhapuri, archived}
+}
+
+func productNames(products []models.Product) []string {
+	names := []string{}
+	for _, p := range products {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func TestMemoryBackendSearch(t *testing.T) {
+	backend := NewMemoryBackend()
+	backend.Load(testCatalog())
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"name", "khussa", []string{"Tilla Work Khussa", "Velvet Khussa"}},
+		{"case insensitive", "VELVET", []string{"Kolhapuri Chappal", "Velvet Khussa"}},
+		{"every word must match", "velvet khussa", []string{"Velvet Khussa"}},
+		{"category", "casual", []string{"Kolhapuri Chappal"}},
+		{"urdu name", "کھسہ", []string{"Tilla Work Khussa"}},
+		{"no match", "sneaker", []string{}},
+		{"archived products are not loaded", "mule", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := backend.Search(context.Background(), models.SearchQuery{Text: tt.text, Page: 1, Limit: 20})
+			if err != nil {
+				t.Fatalf("Search = %v", err)
+			}
+			if got := productNames(result.Products); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+			if result.Total != int64(len(tt.want)) {
+				t.Errorf("Total = %d, want %d", result.Total, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestMemoryBackendIndexAndDelete(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryBackend()
+	backend.Load(testCatalog()[:1])
+
+	added := testProduct(5, "Mirror Work Khussa", "Festive", 3000)
+	if err := backend.IndexProduct(ctx, &added); err != nil {
+		t.Fatalf("IndexProduct = %v", err)
+	}
+	result, _ := backend.Search(ctx, models.SearchQuery{Text: "khussa", Page: 1, Limit: 20})
+	if got, want := productNames(result.Products), []string{"Mirror Work Khussa", "Velvet Khussa"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("after IndexProduct Search = %v, want %v", got, want)
+	}
+
+	if err := backend.DeleteProduct(ctx, added.ID.Hex()); err != nil {
+		t.Fatalf("DeleteProduct = %v", err)
+	}
+	if err := backend.DeleteProduct(ctx, added.ID.Hex()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second DeleteProduct = %v, want ErrNotFound", err)
+	}
+	result, _ = backend.Search(ctx, models.SearchQuery{Text: "khussa", Page: 1, Limit: 20})
+	if got, want := productNames(result.Products), []string{"Velvet Khussa"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after DeleteProduct Search = %v, want %v", got, want)
+	}
+}
+
+func TestMemoryBackendSuggest(t *testing.T) {
+	backend := NewMemoryBackend()
+	backend.Load(testCatalog())
+
+	tests := []struct {
+		prefix string
+		size   int
+		want   []models.Suggestion
+	}{
+		{"kh", 10, []models.Suggestion{
+			{Type: models.SuggestionProduct, Text: "Tilla Work Khussa"},
+			{Type: models.SuggestionProduct, Text: "Velvet Khussa"},
+		}},
+		{"BRI", 10, []models.Suggestion{{Type: models.SuggestionCategory, Text: "Bridal"}}},
+		{"k", 1, []models.Suggestion{{Type: models.SuggestionProduct, Text: "Kolhapuri Chappal"}}},
+		{"elvet", 10, []models.Suggestion{}},
+	}
+	for _, tt := range tests {
+		suggestions, err := backend.Suggest(context.Background(), tt.prefix, tt.size)
+		if err != nil {
+			t.Fatalf("Suggest(%q) = %v", tt.prefix, err)
+		}
+		got := make([]models.Suggestion, len(suggestions))
+		for i, s := range suggestions {
+			got[i] = models.Suggestion{Type: s.Type, Text: s.Text}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Suggest(%q, %d) = %v, want %v", tt.prefix, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestHasWordPrefix(t *testing.T) {
+	tests := []struct {
+		text   string
+		prefix string
+		want   bool
+	}{
+		{"Velvet Khussa", "vel", true},
+		{"Velvet Khussa", "khu", true},
+		{"Velvet Khussa", "elv", false},
+		{"Velvet Khussa", "hussa", false},
+		// A match inside a word doesn't hide a later one at a word start
+		{"Akhu Khussa", "khu", true},
+		{"Velvet Khussa", "velvet khussa", true},
+		{"Velvet", "velvets", false},
+		{"", "a", false},
+	}
+	for _, tt := range tests {
+		if got := hasWordPrefix(tt.text, tt.prefix); got != tt.want {
+			t.Errorf("hasWordPrefix(%q, %q) = %v, want %v", tt.text, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestBuildSuggestions(t *testing.T) {
+	products := []models.Product{
+		testProduct(1, "Velvet Khussa", "Bridal", 4500),
+		testProduct(2, "Tilla Khussa", "Bridal", 6500),
+	}
+
+	tests := []struct {
+		name       string
+		categories []string
+		size       int
+		want       []string
+	}{
+		{"categories first, sorted", []string{"Casual", "Bridal"}, 10, []string{"Bridal", "Casual", "Velvet Khussa", "Tilla Khussa"}},
+		{"at most three categories", []string{"D", "C", "B", "A"}, 10, []string{"A", "B", "C", "Velvet Khussa", "Tilla Khussa"}},
+		{"products fill up to size", []string{"Bridal"}, 2, []string{"Bridal", "Velvet Khussa"}},
+		{"no categories", nil, 10, []string{"Velvet Khussa", "Tilla Khussa"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suggestions := buildSuggestions(products, tt.categories, tt.size)
+			got := []string{}
+			for _, s := range suggestions {
+				got = append(got, s.Text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildSuggestions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	suggestion := buildSuggestions(products[:1], nil, 1)[0]
+	want := models.Suggestion{
+		Type:      models.SuggestionProduct,
+		Text:      "Velvet Khussa",
+		ProductID: products[0].ID.Hex(),
+		Category:  "Bridal",
+		Price:     4500,
+	}
+	if suggestion != want {
+		t.Errorf("product suggestion = %+v, want %+v", suggestion, want)
+	}
+}
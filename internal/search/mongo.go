@@ -0,0 +1,86 @@
+package search
+
+import (
+	"context"
+	"sort"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"github.com/khusa-mahal/backend/internal/repository/mongodb"
+)
+
+// maxCategorySuggestions caps how many suggestions may be categories
+const maxCategorySuggestions = 3
+
+// MongoBackend searches the products collection directly, using the text
+// index and counting facets in memory. Writes are no-ops since it reads the
+// source of truth.
+type MongoBackend struct {
+	repo *mongodb.ProductRepository
+}
+
+func NewMongoBackend(repo *mongodb.ProductRepository) *MongoBackend {
+	return &MongoBackend{repo: repo}
+}
+
+func (b *MongoBackend) Name() string {
+	return BackendMongo
+}
+
+// Search matches whole words through the $text index. When that finds
+// nothing (a partial word, or Urdu script, which the text index doesn't
+// cover) it falls back to a case-insensitive substring match.
+func (b *MongoBackend) Search(ctx context.Context, q models.SearchQuery) (*models.SearchResult, error) {
+	products, err := b.repo.TextSearch(ctx, q.Text)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		if products, err = b.repo.Search(ctx, q.Text); err != nil {
+			return nil, err
+		}
+	}
+	return facetProducts(products, q), nil
+}
+
+func (b *MongoBackend) Suggest(ctx context.Context, prefix string, size int) ([]models.Suggestion, error) {
+	products, categories, err := b.repo.Suggest(ctx, prefix, int64(size))
+	if err != nil {
+		return nil, err
+	}
+	return buildSuggestions(products, categories, size), nil
+}
+
+func (b *MongoBackend) IndexProduct(ctx context.Context, product *models.Product) error {
+	return nil
+}
+
+func (b *MongoBackend) DeleteProduct(ctx context.Context, id string) error {
+	return nil
+}
+
+// buildSuggestions lists up to three categories, then products, size in all
+func buildSuggestions(products []models.Product, categories []string, size int) []models.Suggestion {
+	sort.Strings(categories)
+	if len(categories) > maxCategorySuggestions {
+		categories = categories[:maxCategorySuggestions]
+	}
+
+	suggestions := make([]models.Suggestion, 0, size)
+	for _, category := range categories {
+		suggestions = append(suggestions, models.Suggestion{Type: models.SuggestionCategory, Text: category})
+	}
+	for _, p := range products {
+		if len(suggestions) >= size {
+			break
+		}
+		suggestions = append(suggestions, models.Suggestion{
+			Type:      models.SuggestionProduct,
+			Text:      p.Name,
+			ProductID: p.ID.Hex(),
+			Category:  p.Category,
+			Image:     p.Image,
+			Price:     p.Price,
+		})
+	}
+	return suggestions
+}
@@ -0,0 +1,105 @@
+// Package search puts the product search implementations behind one
+// interface, so the server can run on Elasticsearch, on MongoDB alone, or
+// fully in memory.
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"github.com/khusa-mahal/backend/internal/repository/elasticsearch"
+	"github.com/khusa-mahal/backend/internal/repository/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Backend names accepted in SEARCH_BACKEND
+const (
+	BackendElasticsearch = "elasticsearch"
+	BackendMongo         = "mongo"
+	BackendMemory        = "memory"
+)
+
+// ErrNotFound is returned by DeleteProduct when the backend holds no
+// document for the product
+var ErrNotFound = elasticsearch.ErrNotFound
+
+// Backend answers storefront searches and receives product changes
+type Backend interface {
+	// Name identifies the backend in logs
+	Name() string
+	// Search runs a faceted search; q must already be normalized
+	Search(ctx context.Context, q models.SearchQuery) (*models.SearchResult, error)
+	// Suggest returns up to size autocomplete entries for a typed prefix
+	Suggest(ctx context.Context, prefix string, size int) ([]models.Suggestion, error)
+	// IndexProduct adds or replaces a live product
+	IndexProduct(ctx context.Context, product *models.Product) error
+	// DeleteProduct removes a product that was deleted or archived
+	DeleteProduct(ctx context.Context, id string) error
+}
+
+// Fallback serves reads from Primary and retries them on Secondary when
+// Primary fails. Writes go to Primary only: Secondary is expected to read
+// from the source of truth, as the MongoDB backend does.
+type Fallback struct {
+	Primary   Backend
+	Secondary Backend
+}
+
+func (f *Fallback) Name() string {
+	return f.Primary.Name() + "+" + f.Secondary.Name()
+}
+
+func (f *Fallback) Search(ctx context.Context, q models.SearchQuery) (*models.SearchResult, error) {
+	result, err := f.Primary.Search(ctx, q)
+	if err == nil {
+		return result, nil
+	}
+	fmt.Printf("⚠️  %s search failed, falling back to %s: %v\n", f.Primary.Name(), f.Secondary.Name(), err)
+	return f.Secondary.Search(ctx, q)
+}
+
+func (f *Fallback) Suggest(ctx context.Context, prefix string, size int) ([]models.Suggestion, error) {
+	suggestions, err := f.Primary.Suggest(ctx, prefix, size)
+	if err == nil {
+		return suggestions, nil
+	}
+	fmt.Printf("⚠️  %s suggest failed, falling back to %s: %v\n", f.Primary.Name(), f.Secondary.Name(), err)
+	return f.Secondary.Suggest(ctx, prefix, size)
+}
+
+func (f *Fallback) IndexProduct(ctx context.Context, product *models.Product) error {
+	return f.Primary.IndexProduct(ctx, product)
+}
+
+func (f *Fallback) DeleteProduct(ctx context.Context, id string) error {
+	return f.Primary.DeleteProduct(ctx, id)
+}
+
+// New returns the backend with the given name. es is nil when no
+// Elasticsearch client could be created, in which case the elasticsearch
+// backend degrades to MongoDB. The elasticsearch backend falls back to
+// MongoDB for reads whenever Elasticsearch fails.
+func New(ctx context.Context, name string, es *elasticsearch.SearchService, repo *mongodb.ProductRepository) (Backend, error) {
+	mongoBackend := NewMongoBackend(repo)
+
+	switch name {
+	case BackendElasticsearch:
+		if es == nil {
+			fmt.Println("⚠️  Elasticsearch client unavailable, searching MongoDB instead")
+			return mongoBackend, nil
+		}
+		return &Fallback{Primary: es, Secondary: mongoBackend}, nil
+	case BackendMongo:
+		return mongoBackend, nil
+	case BackendMemory:
+		products, err := repo.GetAll(ctx, bson.M{"isArchived": bson.M{"$ne": true}})
+		if err != nil {
+			return nil, err
+		}
+		memory := NewMemoryBackend()
+		memory.Load(products)
+		return memory, nil
+	}
+	return nil, fmt.Errorf("unknown search backend %q", name)
+}
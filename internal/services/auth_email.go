@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrEmailTaken is returned when another account already uses the email
+	ErrEmailTaken = errors.New("email is already in use")
+	// ErrInvalidEmail is returned for addresses that can't be parsed
+	ErrInvalidEmail = errors.New("invalid email address")
+	// ErrNoPendingEmail is returned when confirming without requesting a change first
+	ErrNoPendingEmail = errors.New("no email change is pending")
+)
+
+// RequestEmailChange records newEmail as pending and sends a code to it. The
+// account email only changes once ConfirmEmailChange succeeds. Whether another
+// account uses newEmail isn't checked here, so the request can't be used to
+// find out; confirming is refused instead, and only whoever reads that inbox
+// gets that far.
+func (s *AuthService) RequestEmailChange(ctx context.Context, userID primitive.ObjectID, newEmail, currentPassword string) error {
+	newEmail = strings.TrimSpace(newEmail)
+	if addr, err := mail.ParseAddress(newEmail); err != nil || addr.Address != newEmail {
+		return ErrInvalidEmail
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword)); err != nil {
+		return ErrIncorrectPassword
+	}
+	if strings.EqualFold(newEmail, user.Email) {
+		return ErrInvalidEmail
+	}
+
+	if err := s.throttleOTPSend(ctx, newEmail, models.OTPPurposeEmailChange); err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdateProfile(ctx, user.ID, bson.M{"pendingEmail": newEmail}); err != nil {
+		return err
+	}
+
+	code, err := s.issueOTP(ctx, newEmail, models.OTPPurposeEmailChange)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.emailService.SendEmailChangeOTP(newEmail, code); err != nil {
+			fmt.Printf("Warning: Failed to send email change OTP to %s: %v\n", newEmail, err)
+		}
+	}()
+
+	return nil
+}
+
+// ConfirmEmailChange verifies the code sent to the pending email and makes it
+// the account email. Every session but keepSessionID is signed out, and the
+// previous address is told about the change.
+func (s *AuthService) ConfirmEmailChange(ctx context.Context, userID primitive.ObjectID, code, keepSessionID string, client ClientInfo) (*models.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.PendingEmail == "" {
+		return nil, ErrNoPendingEmail
+	}
+
+	if err := s.verifyOTP(ctx, user.PendingEmail, models.OTPPurposeEmailChange, code, client); err != nil {
+		return nil, err
+	}
+
+	// The unique index on email settles races with sign-ups and other changes
+	if err := s.userRepo.ChangeEmail(ctx, user.ID, user.PendingEmail); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrEmailTaken
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNoPendingEmail
+		}
+		return nil, err
+	}
+	if err := s.RevokeAllSessions(ctx, user.ID, keepSessionID, "email changed"); err != nil {
+		return nil, err
+	}
+
+	oldEmail, newEmail := user.Email, user.PendingEmail
+	go func() {
+		if err := s.emailService.SendEmailChangedNotice(oldEmail, newEmail); err != nil {
+			fmt.Printf("Warning: Failed to send email change notice to %s: %v\n", oldEmail, err)
+		}
+	}()
+
+	return s.userRepo.FindByID(ctx, user.ID)
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const otpTTL = 10 * time.Minute
+
+// ErrInvalidOTP covers wrong, expired and exhausted codes alike
+var ErrInvalidOTP = errors.New("invalid or expired OTP")
+
+// RateLimitError is returned when a code is requested or guessed too often
+type RateLimitError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return e.Message
+}
+
+// RetryAfterSeconds rounds RetryAfter up for the Retry-After header
+func (e *RateLimitError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+func throttleKey(kind, value string) string {
+	return "otp:" + kind + ":" + strings.ToLower(strings.TrimSpace(value))
+}
+
+// hashOTP binds the code to its email and purpose so a stored hash can't be
+// replayed elsewhere, and keys it with the server secret so six digits can't
+// be brute-forced offline from a database dump.
+func (s *AuthService) hashOTP(email, purpose, code string) string {
+	mac := hmac.New(sha256.New, s.jwtSecret)
+	mac.Write([]byte(purpose + "\x00" + strings.ToLower(email) + "\x00" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// throttleOTPSend enforces the resend cooldown and the hourly cap for an
+// email. It runs before the user lookup so the response is the same for
+// unknown emails.
+func (s *AuthService) throttleOTPSend(ctx context.Context, email, purpose string) error {
+	count, resetAt, err := s.throttleRepo.Hit(ctx, throttleKey("cooldown:"+purpose, email), s.otpConfig.ResendCooldown)
+	if err != nil {
+		return err
+	}
+	if count > 1 {
+		return &RateLimitError{Message: "please wait before requesting another code", RetryAfter: time.Until(resetAt)}
+	}
+
+	count, resetAt, err = s.throttleRepo.Hit(ctx, throttleKey("sends", email), time.Hour)
+	if err != nil {
+		return err
+	}
+	if count > s.otpConfig.MaxSendsPerHour {
+		return &RateLimitError{Message: "too many codes requested, please try again later", RetryAfter: time.Until(resetAt)}
+	}
+	return nil
+}
+
+// issueOTP replaces any outstanding code for email and purpose with a new one
+// and returns it in plain text for sending
+func (s *AuthService) issueOTP(ctx context.Context, email, purpose string) (string, error) {
+	code, err := s.generateOTP(6)
+	if err != nil {
+		return "", err
+	}
+
+	_ = s.otpRepo.DeleteByEmail(ctx, email, purpose)
+	otp := &models.OTP{
+		Email:     email,
+		Purpose:   purpose,
+		CodeHash:  s.hashOTP(email, purpose, code),
+		ExpiresAt: time.Now().Add(otpTTL),
+	}
+	if err := s.otpRepo.Save(ctx, otp); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// verifyOTP checks a code and consumes it. Every failure counts towards the
+// per-email and per-IP lockouts, and each code allows only a few guesses.
+func (s *AuthService) verifyOTP(ctx context.Context, email, purpose, code string, client ClientInfo) error {
+	keys := []string{throttleKey("failures:email", email)}
+	limits := []int{s.otpConfig.LockoutThreshold}
+	if client.IP != "" {
+		keys = append(keys, throttleKey("failures:ip", client.IP))
+		limits = append(limits, s.otpConfig.IPLockoutThreshold)
+	}
+
+	for i, key := range keys {
+		count, resetAt, err := s.throttleRepo.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if count >= limits[i] {
+			return &RateLimitError{Message: "too many failed attempts, please try again later", RetryAfter: time.Until(resetAt)}
+		}
+	}
+
+	otp, err := s.otpRepo.ClaimAttempt(ctx, email, purpose, s.otpConfig.MaxAttempts)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	if err != nil || !hmac.Equal([]byte(otp.CodeHash), []byte(s.hashOTP(email, purpose, code))) {
+		s.recordOTPFailure(ctx, keys)
+		return ErrInvalidOTP
+	}
+
+	consumed, err := s.otpRepo.Consume(ctx, otp.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidOTP
+	}
+
+	_ = s.throttleRepo.Reset(ctx, keys[0])
+	return nil
+}
+
+func (s *AuthService) recordOTPFailure(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if _, _, err := s.throttleRepo.Hit(ctx, key, s.otpConfig.LockoutWindow); err != nil {
+			fmt.Printf("⚠️  Failed to record OTP failure for %s: %v\n", key, err)
+		}
+	}
+}
+
+// ResendOTP sends a new signup code to an unverified account. Unknown and
+// already verified emails get the same response without an email being sent.
+func (s *AuthService) ResendOTP(ctx context.Context, email string) error {
+	if err := s.throttleOTPSend(ctx, email, models.OTPPurposeSignup); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil || user.IsVerified {
+		return nil
+	}
+
+	code, err := s.issueOTP(ctx, user.Email, models.OTPPurposeSignup)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.emailService.SendOTP(user.Email, code); err != nil {
+			fmt.Printf("Warning: Failed to send OTP email to %s: %v\n", user.Email, err)
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/khusa-mahal/backend/internal/models"
+)
+
+func TestHashOTP(t *testing.T) {
+	s := &AuthService{jwtSecret: []byte("test-secret")}
+	base := s.hashOTP("ayesha@example.com", models.OTPPurposeSignup, "123456")
+
+	mac := hmac.New(sha256.New, []byte("test-secret"))
+	mac.Write([]byte("signup\x00ayesha@example.com\x00123456"))
+	if want := hex.EncodeToString(mac.Sum(nil)); base != want {
+		t.Fatalf("hashOTP = %s, want %s", base, want)
+	}
+
+	tests := []struct {
+		name    string
+		service *AuthService
+		email   string
+		purpose string
+		code    string
+		same    bool
+	}{
+		{"same inputs", s, "ayesha@example.com", models.OTPPurposeSignup, "123456", true},
+		{"email case ignored", s, "Ayesha@Example.com", models.OTPPurposeSignup, "123456", true},
+		{"other code", s, "ayesha@example.com", models.OTPPurposeSignup, "123457", false},
+		{"other email", s, "bilal@example.com", models.OTPPurposeSignup, "123456", false},
+		{"other purpose", s, "ayesha@example.com", models.OTPPurposePasswordReset, "123456", false},
+		{"other secret", &AuthService{jwtSecret: []byte("other-secret")}, "ayesha@example.com", models.OTPPurposeSignup, "123456", false},
+		// The separators keep fields from running into each other
+		{"shifted boundary", s, "ayesha@example.com\x00123", models.OTPPurposeSignup, "456", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.service.hashOTP(tt.email, tt.purpose, tt.code)
+			if (got == base) != tt.same {
+				t.Errorf("hashOTP = %s, same as base = %v, want %v", got, got == base, tt.same)
+			}
+		})
+	}
+}
+
+func TestGenerateOTP(t *testing.T) {
+	s := &AuthService{}
+	for i := 0; i < 20; i++ {
+		code, err := s.generateOTP(6)
+		if err != nil {
+			t.Fatalf("generateOTP = %v", err)
+		}
+		if len(code) != 6 {
+			t.Fatalf("generateOTP = %q, want 6 digits", code)
+		}
+		for _, c := range code {
+			if c < '0' || c > '9' {
+				t.Fatalf("generateOTP = %q, want only digits", code)
+			}
+		}
+	}
+}
+
+func TestThrottleKey(t *testing.T) {
+	if got, want := throttleKey("sends", " Ayesha@Example.com "), "otp:sends:ayesha@example.com"; got != want {
+		t.Errorf("throttleKey = %q, want %q", got, want)
+	}
+}
+
+func TestRateLimitErrorRetryAfterSeconds(t *testing.T) {
+	tests := []struct {
+		retryAfter time.Duration
+		want       int
+	}{
+		{30 * time.Second, 30},
+		{1500 * time.Millisecond, 2},
+		{time.Millisecond, 1},
+		{0, 0},
+	}
+	for _, tt := range tests {
+		err := &RateLimitError{RetryAfter: tt.retryAfter}
+		if got := err.RetryAfterSeconds(); got != tt.want {
+			t.Errorf("RetryAfterSeconds(%s) = %d, want %d", tt.retryAfter, got, tt.want)
+		}
+	}
+}
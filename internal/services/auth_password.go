@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const minPasswordLength = 8
+
+var (
+	// ErrInvalidResetCode is deliberately the same whether the email is unknown or the code is wrong
+	ErrInvalidResetCode = errors.New("invalid or expired reset code")
+	// ErrWeakPassword is returned for new passwords that are too short
+	ErrWeakPassword = fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	// ErrIncorrectPassword is returned when the current password doesn't match
+	ErrIncorrectPassword = errors.New("current password is incorrect")
+)
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return ErrWeakPassword
+	}
+	return nil
+}
+
+// ForgotPassword emails a reset code to a verified account. It returns nil
+// for unknown emails too so callers can't probe which emails are registered.
+func (s *AuthService) ForgotPassword(ctx context.Context, email string) error {
+	if err := s.throttleOTPSend(ctx, email, models.OTPPurposePasswordReset); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil || !user.IsVerified {
+		return nil
+	}
+
+	// Only the latest code is valid
+	otpCode, err := s.issueOTP(ctx, user.Email, models.OTPPurposePasswordReset)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.emailService.SendPasswordResetOTP(user.Email, otpCode); err != nil {
+			fmt.Printf("Warning: Failed to send password reset email to %s: %v\n", user.Email, err)
+		}
+	}()
+
+	return nil
+}
+
+// ResetPassword sets a new password using an emailed reset code and signs the
+// user out of every session
+func (s *AuthService) ResetPassword(ctx context.Context, email, code, newPassword string, client ClientInfo) error {
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+
+	if err := s.verifyOTP(ctx, email, models.OTPPurposePasswordReset, code, client); err != nil {
+		if errors.Is(err, ErrInvalidOTP) {
+			return ErrInvalidResetCode
+		}
+		return err
+	}
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return ErrInvalidResetCode
+	}
+
+	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, string(hashedBytes)); err != nil {
+		return err
+	}
+
+	return s.RevokeAllSessions(ctx, user.ID, "", "password reset")
+}
+
+// ChangePassword sets a new password for a signed-in user after checking the
+// current one, and signs out every session except keepSessionID
+func (s *AuthService) ChangePassword(ctx context.Context, userID primitive.ObjectID, currentPassword, newPassword, keepSessionID string) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword)); err != nil {
+		return ErrIncorrectPassword
+	}
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+
+	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, string(hashedBytes)); err != nil {
+		return err
+	}
+
+	return s.RevokeAllSessions(ctx, user.ID, keepSessionID, "password changed")
+}
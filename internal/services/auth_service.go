@@ -6,28 +6,46 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/khusa-mahal/backend/internal/cache"
+	"github.com/khusa-mahal/backend/internal/config"
 	"github.com/khusa-mahal/backend/internal/models"
 	"github.com/khusa-mahal/backend/internal/repository/mongodb"
+	"github.com/khusa-mahal/backend/internal/repository/redis"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService struct {
 	userRepo     *mongodb.UserRepository
 	otpRepo      *mongodb.OTPRepository
+	sessionRepo  *mongodb.SessionRepository
+	throttleRepo *mongodb.ThrottleRepository
+	cache        *redis.Cache
 	emailService *EmailService
 	jwtSecret    []byte
+	accessTTL    time.Duration
+	refreshTTL   time.Duration
+	otpConfig    config.OTPConfig
+	liveSessions *cache.LRU // Sessions recently confirmed live in MongoDB
 }
 
-func NewAuthService(userRepo *mongodb.UserRepository, otpRepo *mongodb.OTPRepository, emailService *EmailService) *AuthService {
+func NewAuthService(userRepo *mongodb.UserRepository, otpRepo *mongodb.OTPRepository, sessionRepo *mongodb.SessionRepository, throttleRepo *mongodb.ThrottleRepository, redisCache *redis.Cache, emailService *EmailService, jwtConfig config.JWTConfig, otpConfig config.OTPConfig) *AuthService {
 	return &AuthService{
 		userRepo:     userRepo,
 		otpRepo:      otpRepo,
+		sessionRepo:  sessionRepo,
+		throttleRepo: throttleRepo,
+		cache:        redisCache,
 		emailService: emailService,
-		jwtSecret:    []byte(os.Getenv("JWT_SECRET")),
+		jwtSecret:    []byte(jwtConfig.Secret),
+		accessTTL:    jwtConfig.Expiry,
+		refreshTTL:   jwtConfig.RefreshExpiry,
+		otpConfig:    otpConfig,
+		liveSessions: cache.NewLRU(maxLiveSessions),
 	}
 }
 
@@ -39,9 +57,11 @@ func (s *AuthService) Register(ctx context.Context, input models.User, password
 		if existingUser.IsVerified {
 			return errors.New("user already exists with this email")
 		}
-		// If exists but not verified, we can resend OTP or overwrite.
-		// For simplicity, we'll overwrite/ignore and just send new OTP logic purely.
-		// Actually, standard is to not allow duplicate keys. Let's assume we proceed.
+		// An unverified account gets a fresh code, subject to the resend limits
+	}
+
+	if err := s.throttleOTPSend(ctx, input.Email, models.OTPPurposeSignup); err != nil {
+		return err
 	}
 
 	// 2. Hash Password
@@ -51,6 +71,7 @@ func (s *AuthService) Register(ctx context.Context, input models.User, password
 	}
 	input.PasswordHash = string(hashedBytes)
 	input.IsVerified = false
+	input.Roles = []string{models.RoleCustomer}
 
 	// 3. Save User (if new)
 	if existingUser == nil {
@@ -59,24 +80,13 @@ func (s *AuthService) Register(ctx context.Context, input models.User, password
 		}
 	}
 
-	// 4. Generate OTP
-	otpCode, err := s.generateOTP(6)
+	// 4. Issue OTP
+	otpCode, err := s.issueOTP(ctx, input.Email, models.OTPPurposeSignup)
 	if err != nil {
 		return err
 	}
 
-	// 5. Save OTP
-	otp := &models.OTP{
-		Email:     input.Email,
-		Code:      otpCode,
-		ExpiresAt: time.Now().Add(10 * time.Minute),
-	}
-	if err := s.otpRepo.Save(ctx, otp); err != nil {
-		return err
-	}
-
-
-	// 6. Send Email (async - don't block signup)
+	// 5. Send Email (async - don't block signup)
 	go func() {
 		if err := s.emailService.SendOTP(input.Email, otpCode); err != nil {
 			// Log error but don't fail registration
@@ -87,56 +97,64 @@ func (s *AuthService) Register(ctx context.Context, input models.User, password
 	return nil
 }
 
-// VerifyOTP checks code and activates user
-func (s *AuthService) VerifyOTP(ctx context.Context, email, code string) (string, *models.User, error) {
-	// 1. Find Valid OTP
-	_, err := s.otpRepo.FindValidOTP(ctx, email, code)
-	if err != nil {
-		return "", nil, errors.New("invalid or expired OTP")
+// VerifyOTP checks code, activates user and signs them in
+func (s *AuthService) VerifyOTP(ctx context.Context, email, code string, client ClientInfo) (*TokenPair, *models.User, error) {
+	// 1. Check and consume the OTP
+	if err := s.verifyOTP(ctx, email, models.OTPPurposeSignup, code, client); err != nil {
+		return nil, nil, err
 	}
 
 	// 2. Mark User Verified
 	if err := s.userRepo.UpdateVerification(ctx, email); err != nil {
-		return "", nil, err
+		return nil, nil, err
 	}
 
-	// 3. Cleanup OTPs
-	_ = s.otpRepo.DeleteByEmail(ctx, email)
-
-	// 4. Generate Token (Auto Login) - get user first to get ID
+	// 3. Start a session (Auto Login) - get user first to get ID
 	user, err := s.userRepo.FindByEmail(ctx, email)
 	if err != nil {
-		return "", nil, err
+		return nil, nil, err
 	}
 
-	token, err := s.GenerateToken(user)
-	return token, user, err
+	tokens, err := s.startSession(ctx, user, client)
+	return tokens, user, err
 }
 
-// Login validates user and returns token
-func (s *AuthService) Login(ctx context.Context, email, password string) (string, *models.User, error) {
+// Login validates user and starts a new session
+func (s *AuthService) Login(ctx context.Context, email, password string, client ClientInfo) (*TokenPair, *models.User, error) {
 	user, err := s.userRepo.FindByEmail(ctx, email)
 	if err != nil {
-		return "", nil, errors.New("invalid credentials")
+		return nil, nil, errors.New("invalid credentials")
 	}
 
 	if !user.IsVerified {
-		return "", nil, errors.New("email not verified")
+		return nil, nil, errors.New("email not verified")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return "", nil, errors.New("invalid credentials")
+		return nil, nil, errors.New("invalid credentials")
 	}
 
-	token, err := s.GenerateToken(user)
-	return token, user, err
+	tokens, err := s.startSession(ctx, user, client)
+	return tokens, user, err
 }
 
-func (s *AuthService) GenerateToken(user *models.User) (string, error) {
+// GenerateToken signs a short-lived access token bound to a session
+func (s *AuthService) GenerateToken(user *models.User, sessionID primitive.ObjectID) (string, error) {
+	roles := user.Roles
+	if len(roles) == 0 {
+		// Accounts created before roles existed are customers
+		roles = []string{models.RoleCustomer}
+	}
+
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"userId": user.ID.Hex(),
 		"email":  user.Email,
-		"exp":    time.Now().Add(24 * time.Hour).Unix(),
+		"roles":  roles,
+		"sid":    sessionID.Hex(),
+		"jti":    uuid.NewString(),
+		"iat":    now.Unix(),
+		"exp":    now.Add(s.accessTTL).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(s.jwtSecret)
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/khusa-mahal/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrInvalidRefreshToken covers unknown, expired and revoked refresh tokens
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused means an already rotated refresh token was presented
+	// again. The whole session is revoked because the token may have leaked.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected, please sign in again")
+	// ErrSessionRevoked is returned for access tokens whose session has ended
+	ErrSessionRevoked = errors.New("session has been revoked")
+)
+
+const (
+	// sessionCheckTTL bounds how long another instance may keep accepting a
+	// session revoked while Redis was unreachable
+	sessionCheckTTL = 10 * time.Second
+	maxLiveSessions = 10000
+)
+
+// TokenPair is returned on sign-in and refresh
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int64  `json:"expiresIn"` // Access token lifetime in seconds
+}
+
+// ClientInfo describes the device a session was started from
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// startSession creates a server-side session and issues its first token pair
+func (s *AuthService) startSession(ctx context.Context, user *models.User, client ClientInfo) (*TokenPair, error) {
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		UserID:    user.ID,
+		TokenHash: hashRefreshToken(refreshToken),
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.GenerateToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.accessTTL.Seconds()),
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new token pair. The presented token
+// is rotated out; presenting it again revokes the session.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, *models.User, error) {
+	if refreshToken == "" {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	hash := hashRefreshToken(refreshToken)
+	session, err := s.sessionRepo.FindByTokenHash(ctx, hash)
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	if session.TokenHash != hash {
+		s.revokeSessions(ctx, []primitive.ObjectID{session.ID}, "refresh token reuse")
+		return nil, nil, ErrRefreshTokenReused
+	}
+
+	newToken, err := newRefreshToken()
+	if err != nil {
+		return nil, nil, err
+	}
+	rotated, err := s.sessionRepo.Rotate(ctx, session.ID, hash, hashRefreshToken(newToken), time.Now().Add(s.refreshTTL))
+	if err != nil {
+		return nil, nil, err
+	}
+	if !rotated {
+		// Another request rotated this token first: the same token was used twice
+		s.revokeSessions(ctx, []primitive.ObjectID{session.ID}, "refresh token reuse")
+		return nil, nil, ErrRefreshTokenReused
+	}
+
+	// Reload the user so role changes take effect on refresh
+	user, err := s.userRepo.FindByID(ctx, session.UserID)
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	accessToken, err := s.GenerateToken(user, session.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: newToken,
+		ExpiresIn:    int64(s.accessTTL.Seconds()),
+	}, user, nil
+}
+
+// Logout ends the session identified by the refresh token or, failing that,
+// by the session ID of the caller's access token
+func (s *AuthService) Logout(ctx context.Context, refreshToken, sessionID string) error {
+	if refreshToken != "" {
+		if session, err := s.sessionRepo.FindByTokenHash(ctx, hashRefreshToken(refreshToken)); err == nil {
+			s.revokeSessions(ctx, []primitive.ObjectID{session.ID}, "logout")
+			return nil
+		}
+	}
+
+	if sid, err := primitive.ObjectIDFromHex(sessionID); err == nil {
+		s.revokeSessions(ctx, []primitive.ObjectID{sid}, "logout")
+		return nil
+	}
+
+	return ErrInvalidRefreshToken
+}
+
+// RevokeAllSessions signs the user out everywhere except keepSessionID (pass
+// an empty string to include the current session)
+func (s *AuthService) RevokeAllSessions(ctx context.Context, userID primitive.ObjectID, keepSessionID, reason string) error {
+	keep, _ := primitive.ObjectIDFromHex(keepSessionID)
+
+	ids, err := s.sessionRepo.RevokeAllForUser(ctx, userID, keep, reason)
+	if err != nil {
+		return err
+	}
+	s.denySessions(ctx, ids)
+	return nil
+}
+
+func (s *AuthService) revokeSessions(ctx context.Context, ids []primitive.ObjectID, reason string) {
+	for _, id := range ids {
+		if err := s.sessionRepo.Revoke(ctx, id, reason); err != nil {
+			fmt.Printf("⚠️  Failed to revoke session %s: %v\n", id.Hex(), err)
+		}
+	}
+	s.denySessions(ctx, ids)
+}
+
+// denySessions puts sessions on the Redis deny list so their outstanding
+// access tokens stop working before they expire
+func (s *AuthService) denySessions(ctx context.Context, ids []primitive.ObjectID) {
+	for _, id := range ids {
+		_ = s.liveSessions.Delete(ctx, id.Hex())
+		if err := s.cache.RevokeSession(ctx, id.Hex(), s.accessTTL); err != nil {
+			fmt.Printf("⚠️  Failed to add session %s to deny list: %v\n", id.Hex(), err)
+		}
+	}
+}
+
+// ValidateToken rejects access tokens whose session has been revoked. The
+// Redis deny list catches revocations at once, but one made while Redis was
+// unreachable never reached it, so MongoDB has the final say. Sessions found
+// live there are remembered for sessionCheckTTL to keep it off the hot path.
+func (s *AuthService) ValidateToken(ctx context.Context, claims jwt.MapClaims) error {
+	sid, _ := claims["sid"].(string)
+	if sid == "" {
+		// Tokens issued before sessions existed carry no sid and can't be
+		// revoked. They are accepted until they expire so that deploying
+		// sessions doesn't sign everyone out.
+		return nil
+	}
+
+	if revoked, err := s.cache.IsSessionRevoked(ctx, sid); err == nil && revoked {
+		return ErrSessionRevoked
+	}
+	if _, err := s.liveSessions.Get(ctx, sid); err == nil {
+		return nil
+	}
+
+	oid, err := primitive.ObjectIDFromHex(sid)
+	if err != nil {
+		return ErrSessionRevoked
+	}
+	active, err := s.sessionRepo.IsActive(ctx, oid)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return ErrSessionRevoked
+	}
+	_ = s.liveSessions.Set(ctx, sid, []byte{1}, sessionCheckTTL)
+	return nil
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"github.com/khusa-mahal/backend/internal/repository/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrCategoryExists is returned when another category already has the slug
+	ErrCategoryExists = errors.New("a category with this slug already exists")
+	// ErrCategoryInUse is returned when deleting a category that still has products
+	ErrCategoryInUse = errors.New("category still has products")
+)
+
+// CategoryService manages categories and keeps the category name stored on
+// products, the search index and the list caches consistent with them
+type CategoryService struct {
+	repo        *mongodb.CategoryRepository
+	productRepo *mongodb.ProductRepository
+	products    *ProductService
+}
+
+func NewCategoryService(repo *mongodb.CategoryRepository, productRepo *mongodb.ProductRepository, products *ProductService) *CategoryService {
+	return &CategoryService{
+		repo:        repo,
+		productRepo: productRepo,
+		products:    products,
+	}
+}
+
+type CategoryInput struct {
+	Name        string `json:"name"`
+	Slug        string `json:"slug"` // Derived from name when empty
+	Description string `json:"description"`
+	Image       string `json:"image"`
+}
+
+func (in *CategoryInput) normalize() error {
+	in.Name = strings.TrimSpace(in.Name)
+	in.Description = strings.TrimSpace(in.Description)
+	in.Image = strings.TrimSpace(in.Image)
+	if strings.TrimSpace(in.Slug) == "" {
+		in.Slug = in.Name
+	}
+	in.Slug = models.Slugify(in.Slug)
+
+	fields := map[string]string{}
+	if in.Name == "" {
+		fields["name"] = "name is required"
+	}
+	if in.Slug == "" {
+		fields["slug"] = "slug must contain letters or digits"
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// MigrationReport summarises a MigrateProductCategories run
+type MigrationReport struct {
+	Categories int         `json:"categories"` // Distinct category names found on unlinked products
+	Linked     int         `json:"linked"`     // Products given a category ID
+	Sync       *SyncReport `json:"sync,omitempty"`
+}
+
+func (s *CategoryService) List(ctx context.Context) ([]models.Category, error) {
+	return s.repo.FindAll(ctx)
+}
+
+func (s *CategoryService) GetBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	return s.repo.FindBySlug(ctx, slug)
+}
+
+// ProductsBySlug returns the live products in a category. Products that
+// haven't been migrated yet are matched on their category name.
+func (s *CategoryService) ProductsBySlug(ctx context.Context, slug string) (*models.Category, []models.Product, error) {
+	category, err := s.repo.FindBySlug(ctx, slug)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	filter := bson.M{
+		"isArchived": bson.M{"$ne": true},
+		"$or": []bson.M{
+			{"categoryId": category.ID},
+			{"categoryId": bson.M{"$exists": false}, "category": category.Name},
+		},
+	}
+	products, err := s.productRepo.GetAll(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	return category, products, nil
+}
+
+func (s *CategoryService) Create(ctx context.Context, input CategoryInput) (*models.Category, error) {
+	if err := input.normalize(); err != nil {
+		return nil, err
+	}
+
+	category := &models.Category{
+		Name:        input.Name,
+		Slug:        input.Slug,
+		Description: input.Description,
+		Image:       input.Image,
+	}
+	if err := s.repo.Create(ctx, category); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrCategoryExists
+		}
+		return nil, err
+	}
+	return category, nil
+}
+
+// Update changes a category. A new name is written to every product in the
+// category, which are then evicted from the cache and reindexed; a new slug
+// retires the cached pages at the old and new slugs.
+func (s *CategoryService) Update(ctx context.Context, id string, input CategoryInput) (*models.Category, *SyncReport, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, nil, mongo.ErrNoDocuments
+	}
+	if err := input.normalize(); err != nil {
+		return nil, nil, err
+	}
+
+	existing, err := s.repo.FindByID(ctx, oid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fields := bson.M{
+		"name":        input.Name,
+		"slug":        input.Slug,
+		"description": input.Description,
+		"image":       input.Image,
+	}
+	if err := s.repo.Update(ctx, oid, fields); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, nil, ErrCategoryExists
+		}
+		return nil, nil, err
+	}
+
+	var report *SyncReport
+	if input.Name != existing.Name {
+		ids, err := s.productRepo.RenameCategory(ctx, oid, input.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		report = s.products.SyncProducts(ctx, hexIDs(ids))
+	}
+	if input.Slug != existing.Slug {
+		s.productRepo.CategoryPagesChanged(ctx, existing.Slug, input.Slug)
+	}
+
+	category, err := s.repo.FindByID(ctx, oid)
+	if err != nil {
+		return nil, nil, err
+	}
+	return category, report, nil
+}
+
+// Delete removes a category that no product uses
+func (s *CategoryService) Delete(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return mongo.ErrNoDocuments
+	}
+
+	existing, err := s.repo.FindByID(ctx, oid)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.productRepo.CountByCategory(ctx, oid)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCategoryInUse
+	}
+	if err := s.repo.Delete(ctx, oid); err != nil {
+		return err
+	}
+
+	// The cached page must not outlive the category
+	s.productRepo.CategoryPagesChanged(ctx, existing.Slug)
+	return nil
+}
+
+// MigrateProductCategories creates a category for every category name found
+// on products without a category ID and links those products to it. It is
+// safe to run repeatedly.
+func (s *CategoryService) MigrateProductCategories(ctx context.Context) (*MigrationReport, error) {
+	names, err := s.productRepo.UnlinkedCategoryNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MigrationReport{}
+	var linked []primitive.ObjectID
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" || models.Slugify(name) == "" {
+			continue
+		}
+		category, err := s.repo.Ensure(ctx, strings.TrimSpace(name))
+		if err != nil {
+			return report, err
+		}
+		ids, err := s.productRepo.LinkCategory(ctx, name, category)
+		if err != nil {
+			return report, err
+		}
+		report.Categories++
+		linked = append(linked, ids...)
+	}
+
+	report.Linked = len(linked)
+	if len(linked) > 0 {
+		report.Sync = s.products.SyncProducts(ctx, hexIDs(linked))
+	}
+	return report, nil
+}
+
+func hexIDs(ids []primitive.ObjectID) []string {
+	hex := make([]string, len(ids))
+	for i, id := range ids {
+		hex[i] = id.Hex()
+	}
+	return hex
+}
@@ -52,6 +52,48 @@ func (s *EmailService) SendOTP(to, code string) error {
 	return nil
 }
 
+func (s *EmailService) SendPasswordResetOTP(to, code string) error {
+	subject := "Reset Your Password - Khusa Mahal"
+	body := fmt.Sprintf("Your password reset code is: %s\n\nThis code will expire in 10 minutes. If you didn't ask to reset your password, you can ignore this email.", code)
+	return s.sendPlain(to, subject, body, "Password reset OTP: "+code)
+}
+
+func (s *EmailService) SendEmailChangeOTP(to, code string) error {
+	subject := "Confirm Your New Email - Khusa Mahal"
+	body := fmt.Sprintf("Your code to confirm this email address is: %s\n\nThis code will expire in 10 minutes. If you didn't ask to change your email, you can ignore this email.", code)
+	return s.sendPlain(to, subject, body, "Email change OTP: "+code)
+}
+
+// SendEmailChangedNotice tells the previous address that the account email was changed
+func (s *EmailService) SendEmailChangedNotice(to, newEmail string) error {
+	subject := "Your Email Was Changed - Khusa Mahal"
+	body := fmt.Sprintf("The email address on your Khusa Mahal account was changed to %s.\n\nIf you didn't make this change, please contact us right away.", newEmail)
+	return s.sendPlain(to, subject, body, "Email changed to "+newEmail)
+}
+
+// sendPlain sends a plain-text email, or logs mockNote when SMTP isn't configured
+func (s *EmailService) sendPlain(to, subject, body, mockNote string) error {
+	message := []byte(fmt.Sprintf("To: %s\r\n"+
+		"Subject: %s\r\n"+
+		"\r\n"+
+		"%s\r\n", to, subject, body))
+
+	// In development/testing if no creds, just log it
+	if s.username == "" || s.password == "" {
+		fmt.Printf(" [MOCK EMAIL] To: %s | %s\n", to, mockNote)
+		return nil
+	}
+
+	auth := smtp.PlainAuth("", s.username, s.password, s.smtpHost)
+	err := smtp.SendMail(s.smtpHost+":"+s.smtpPort, auth, s.username, []string{to}, message)
+	if err != nil {
+		fmt.Printf("Failed to send email %q: %v\n", subject, err)
+		return err
+	}
+
+	return nil
+}
+
 func (s *EmailService) SendOrderConfirmationEmail(to string, orderID string, items []models.OrderDetailsItem, shippingAddress models.Address, total float64) error {
 	from := s.username
 	subject := "Order Confirmation - Khusa Mahal"
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"github.com/khusa-mahal/backend/internal/repository/mongodb"
+)
+
+// OutOfStockItem describes an order line that could not be reserved
+type OutOfStockItem struct {
+	Index     int    `json:"index"`
+	ProductID string `json:"productId"`
+	Name      string `json:"name"`
+	Size      string `json:"size"`
+	Color     string `json:"color"`
+	Requested int    `json:"requested"`
+}
+
+// OutOfStockError lists every order line that is out of stock
+type OutOfStockError struct {
+	Items []OutOfStockItem `json:"items"`
+}
+
+func (e *OutOfStockError) Error() string {
+	if len(e.Items) == 1 {
+		item := e.Items[0]
+		return fmt.Sprintf("%s (size %s, %s) is out of stock", item.Name, item.Size, item.Color)
+	}
+	return fmt.Sprintf("%d items are out of stock", len(e.Items))
+}
+
+// reserveStock decrements stock for every line. If any line fails, the lines
+// already reserved are released again so the order leaves stock untouched.
+func (s *OrderService) reserveStock(ctx context.Context, items []models.OrderItem) error {
+	var reserved []models.OrderItem
+	outOfStock := &OutOfStockError{}
+
+	for i, item := range items {
+		err := s.productRepo.ReserveStock(ctx, item.ProductID, item.SelectedSize, item.SelectedColor, item.Quantity)
+		if errors.Is(err, mongodb.ErrInsufficientStock) {
+			outOfStock.Items = append(outOfStock.Items, OutOfStockItem{
+				Index:     i,
+				ProductID: item.ProductID.Hex(),
+				Name:      item.Name,
+				Size:      item.SelectedSize,
+				Color:     item.SelectedColor,
+				Requested: item.Quantity,
+			})
+			continue
+		}
+		if err != nil {
+			s.releaseStock(ctx, reserved)
+			return err
+		}
+		reserved = append(reserved, item)
+	}
+
+	if len(outOfStock.Items) > 0 {
+		s.releaseStock(ctx, reserved)
+		return outOfStock
+	}
+	return nil
+}
+
+// releaseStock returns stock for the given lines, logging lines it could not restore
+func (s *OrderService) releaseStock(ctx context.Context, items []models.OrderItem) {
+	for _, item := range items {
+		if err := s.productRepo.ReleaseStock(ctx, item.ProductID, item.SelectedSize, item.SelectedColor, item.Quantity); err != nil {
+			fmt.Printf(" [ERROR] Failed to release %d x %s (%s/%s): %v\n", item.Quantity, item.ProductID.Hex(), item.SelectedSize, item.SelectedColor, err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// priceTolerance absorbs float rounding differences between client and server totals
+const priceTolerance = 0.01
+
+// OrderQuote is the server-side price breakdown for a set of order lines
+type OrderQuote struct {
+	Items        []models.OrderItem `json:"items"`
+	SubTotal     float64            `json:"subTotal"`
+	ShippingCost float64            `json:"shippingCost"`
+	Total        float64            `json:"total"`
+}
+
+// InvalidOrderItemError reports an order line that cannot be priced
+type InvalidOrderItemError struct {
+	Index     int    `json:"index"`
+	ProductID string `json:"productId"`
+	Reason    string `json:"reason"`
+}
+
+func (e *InvalidOrderItemError) Error() string {
+	return fmt.Sprintf("item %d (%s): %s", e.Index, e.ProductID, e.Reason)
+}
+
+// PriceMismatchError is returned when client-supplied totals differ from the server quote
+type PriceMismatchError struct {
+	Expected OrderQuote         `json:"expected"`
+	Received map[string]float64 `json:"received"`
+}
+
+func (e *PriceMismatchError) Error() string {
+	return "order totals do not match current prices"
+}
+
+// unitPrice returns what a single unit of the product costs right now.
+// Seeded products store the already discounted price in Price alongside
+// OriginalPrice, so Discount is only applied when no OriginalPrice is set.
+func unitPrice(product *models.Product) float64 {
+	price := product.Price
+	if product.OriginalPrice == nil && product.Discount != nil && *product.Discount > 0 && *product.Discount < 100 {
+		price = price * (1 - *product.Discount/100)
+	}
+	return roundPrice(price)
+}
+
+func roundPrice(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasColor(colors []models.ColorOption, name string) bool {
+	for _, c := range colors {
+		if strings.EqualFold(c.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// QuoteOrder prices the given lines from the catalogue and snapshots product details
+func (s *OrderService) QuoteOrder(ctx context.Context, items []models.CartItem) (*OrderQuote, error) {
+	quote := &OrderQuote{Items: make([]models.OrderItem, 0, len(items))}
+
+	for i, item := range items {
+		if item.Quantity < 1 {
+			return nil, &InvalidOrderItemError{Index: i, ProductID: item.ProductID.Hex(), Reason: "quantity must be at least 1"}
+		}
+
+		product, err := s.productRepo.GetByID(ctx, item.ProductID.Hex())
+		if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && product == nil) {
+			return nil, &InvalidOrderItemError{Index: i, ProductID: item.ProductID.Hex(), Reason: "product not found"}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if product.IsArchived {
+			return nil, &InvalidOrderItemError{Index: i, ProductID: item.ProductID.Hex(), Reason: "product is no longer available"}
+		}
+
+		size, color := item.SelectedSize, item.SelectedColor
+		if len(product.Variants) > 0 {
+			variant := product.FindVariant(size, color)
+			if variant == nil {
+				return nil, &InvalidOrderItemError{Index: i, ProductID: item.ProductID.Hex(), Reason: fmt.Sprintf("size %q in %q is not available", size, color)}
+			}
+			// Store the catalogue spelling so stock reservations match the variant exactly
+			size, color = variant.Size, variant.Color
+		} else {
+			if size != "" && len(product.Sizes) > 0 && !containsFold(product.Sizes, size) {
+				return nil, &InvalidOrderItemError{Index: i, ProductID: item.ProductID.Hex(), Reason: "size " + size + " is not available"}
+			}
+			if color != "" && len(product.Colors) > 0 && !hasColor(product.Colors, color) {
+				return nil, &InvalidOrderItemError{Index: i, ProductID: item.ProductID.Hex(), Reason: "color " + color + " is not available"}
+			}
+		}
+
+		price := unitPrice(product)
+		line := models.OrderItem{
+			ProductID:     product.ID,
+			Name:          product.Name,
+			Image:         product.Image,
+			UnitPrice:     price,
+			Quantity:      item.Quantity,
+			SelectedSize:  size,
+			SelectedColor: color,
+			LineTotal:     roundPrice(price * float64(item.Quantity)),
+		}
+		quote.Items = append(quote.Items, line)
+		quote.SubTotal += line.LineTotal
+	}
+
+	quote.SubTotal = roundPrice(quote.SubTotal)
+	quote.ShippingCost = s.shippingCost(quote.SubTotal)
+	quote.Total = roundPrice(quote.SubTotal + quote.ShippingCost)
+
+	return quote, nil
+}
+
+func (s *OrderService) shippingCost(subTotal float64) float64 {
+	if s.pricing.FreeShippingThreshold > 0 && subTotal >= s.pricing.FreeShippingThreshold {
+		return 0
+	}
+	return roundPrice(s.pricing.ShippingFlatRate)
+}
+
+// checkClientTotals compares the totals the client displayed against the quote.
+// Clients that don't send totals (all zero) are charged the quoted amount.
+func checkClientTotals(quote *OrderQuote, subTotal, shippingCost, total float64) error {
+	if subTotal == 0 && shippingCost == 0 && total == 0 {
+		return nil
+	}
+
+	if math.Abs(quote.SubTotal-subTotal) > priceTolerance ||
+		math.Abs(quote.ShippingCost-shippingCost) > priceTolerance ||
+		math.Abs(quote.Total-total) > priceTolerance {
+		return &PriceMismatchError{
+			Expected: *quote,
+			Received: map[string]float64{
+				"subTotal":     subTotal,
+				"shippingCost": shippingCost,
+				"total":        total,
+			},
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/khusa-mahal/backend/internal/config"
+	"github.com/khusa-mahal/backend/internal/models"
+)
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestUnitPrice(t *testing.T) {
+	tests := []struct {
+		name    string
+		product models.Product
+		want    float64
+	}{
+		{"plain price", models.Product{Price: 4500}, 4500},
+		{"discount applied", models.Product{Price: 4000, Discount: floatPtr(25)}, 3000},
+		{"discount rounded to paisa", models.Product{Price: 999.99, Discount: floatPtr(33)}, 669.99},
+		// Seeded products already store the discounted price
+		{"original price set", models.Product{Price: 3000, OriginalPrice: floatPtr(4000), Discount: floatPtr(25)}, 3000},
+		{"zero discount", models.Product{Price: 4500, Discount: floatPtr(0)}, 4500},
+		{"discount out of range", models.Product{Price: 4500, Discount: floatPtr(100)}, 4500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unitPrice(&tt.product); got != tt.want {
+				t.Errorf("unitPrice = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShippingCost(t *testing.T) {
+	tests := []struct {
+		name     string
+		pricing  config.PricingConfig
+		subTotal float64
+		want     float64
+	}{
+		{"below threshold", config.PricingConfig{ShippingFlatRate: 250, FreeShippingThreshold: 5000}, 4999.99, 250},
+		{"at threshold", config.PricingConfig{ShippingFlatRate: 250, FreeShippingThreshold: 5000}, 5000, 0},
+		{"no threshold", config.PricingConfig{ShippingFlatRate: 250}, 100000, 250},
+		{"free shipping", config.PricingConfig{}, 100, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &OrderService{pricing: tt.pricing}
+			if got := s.shippingCost(tt.subTotal); got != tt.want {
+				t.Errorf("shippingCost(%v) = %v, want %v", tt.subTotal, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteOrderRejectsQuantityBelowOne(t *testing.T) {
+	// The quantity is checked before the product is looked up, so no
+	// repository is needed
+	s := &OrderService{}
+
+	for _, quantity := range []int{0, -1} {
+		_, err := s.QuoteOrder(context.Background(), []models.CartItem{{Quantity: quantity}})
+
+		var invalid *InvalidOrderItemError
+		if !errors.As(err, &invalid) || invalid.Index != 0 {
+			t.Errorf("QuoteOrder(quantity %d) error = %v, want InvalidOrderItemError for item 0", quantity, err)
+		}
+	}
+}
+
+func TestCheckClientTotals(t *testing.T) {
+	quote := &OrderQuote{SubTotal: 4500, ShippingCost: 250, Total: 4750}
+
+	tests := []struct {
+		name                          string
+		subTotal, shippingCost, total float64
+		wantMismatch                  bool
+	}{
+		{"matching", 4500, 250, 4750, false},
+		{"no totals sent", 0, 0, 0, false},
+		{"within rounding tolerance", 4500.005, 250, 4749.995, false},
+		{"stale sub total", 4000, 250, 4750, true},
+		{"free shipping assumed", 4500, 0, 4500, true},
+		{"only total sent", 0, 0, 4750, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkClientTotals(quote, tt.subTotal, tt.shippingCost, tt.total)
+
+			var mismatch *PriceMismatchError
+			if got := errors.As(err, &mismatch); got != tt.wantMismatch {
+				t.Fatalf("checkClientTotals error = %v, want mismatch %v", err, tt.wantMismatch)
+			}
+			if mismatch == nil {
+				return
+			}
+			if mismatch.Expected.Total != quote.Total {
+				t.Errorf("Expected total = %v, want %v", mismatch.Expected.Total, quote.Total)
+			}
+			if mismatch.Received["total"] != tt.total {
+				t.Errorf("Received total = %v, want %v", mismatch.Received["total"], tt.total)
+			}
+		})
+	}
+}
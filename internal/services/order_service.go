@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/khusa-mahal/backend/internal/config"
 	"github.com/khusa-mahal/backend/internal/models"
 	"github.com/khusa-mahal/backend/internal/repository/mongodb"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -16,16 +17,18 @@ type OrderService struct {
 	paymentService *PaymentService
 	emailService   *EmailService
 	userRepo       *mongodb.UserRepository    // To get user email
-	productRepo    *mongodb.ProductRepository // To get product details for email
+	productRepo    *mongodb.ProductRepository // To price order lines
+	pricing        config.PricingConfig
 }
 
-func NewOrderService(orderRepo *mongodb.OrderRepository, paymentService *PaymentService, emailService *EmailService, userRepo *mongodb.UserRepository, productRepo *mongodb.ProductRepository) *OrderService {
+func NewOrderService(orderRepo *mongodb.OrderRepository, paymentService *PaymentService, emailService *EmailService, userRepo *mongodb.UserRepository, productRepo *mongodb.ProductRepository, pricing config.PricingConfig) *OrderService {
 	return &OrderService{
 		orderRepo:      orderRepo,
 		paymentService: paymentService,
 		emailService:   emailService,
 		userRepo:       userRepo,
 		productRepo:    productRepo,
+		pricing:        pricing,
 	}
 }
 
@@ -37,47 +40,120 @@ func (s *OrderService) GetUserOrders(ctx context.Context, userID string) ([]mode
 	return s.orderRepo.FindByUserID(ctx, oid)
 }
 
-func (s *OrderService) CreateOrder(ctx context.Context, userID string, items []models.CartItem, shippingAddress models.Address, paymentMethod string, paymentDetails map[string]interface{}, subTotal, shippingCost, total float64) (*models.Order, error) {
+// CreateOrderInput carries the checkout request. The totals are what the client
+// displayed to the customer and are only used to detect stale prices.
+type CreateOrderInput struct {
+	Items           []models.CartItem
+	AddressID       string // Saved address to ship to; takes precedence over ShippingAddress
+	ShippingAddress models.Address
+	PaymentMethod   string
+	PaymentDetails  map[string]interface{}
+	SubTotal        float64
+	ShippingCost    float64
+	Total           float64
+}
+
+// resolveShippingAddress picks the saved address named by AddressID, the
+// inline address, or failing both the user's default shipping address
+func (s *OrderService) resolveShippingAddress(ctx context.Context, userID primitive.ObjectID, input CreateOrderInput) (models.Address, error) {
+	if input.AddressID == "" && input.ShippingAddress != (models.Address{}) {
+		return input.ShippingAddress, nil
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return models.Address{}, err
+	}
+
+	if input.AddressID == "" {
+		if saved := user.DefaultShippingAddress(); saved != nil {
+			return saved.Address, nil
+		}
+		return input.ShippingAddress, nil
+	}
+
+	addressID, err := primitive.ObjectIDFromHex(input.AddressID)
+	if err != nil {
+		return models.Address{}, ErrAddressNotFound
+	}
+	saved := user.FindAddress(addressID)
+	if saved == nil {
+		return models.Address{}, ErrAddressNotFound
+	}
+	return saved.Address, nil
+}
+
+func (s *OrderService) CreateOrder(ctx context.Context, userID string, input CreateOrderInput) (*models.Order, error) {
 	// 1. Validate inputs (simplified)
-	if len(items) == 0 {
+	if len(input.Items) == 0 {
 		return nil, errors.New("cart is empty")
 	}
 
-	// 2. Process Payment
-	paymentResult, err := s.paymentService.ProcessPayment(total, "PKR", paymentMethod, paymentDetails)
+	userOID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	shippingAddress, err := s.resolveShippingAddress(ctx, userOID, input)
 	if err != nil {
 		return nil, err
 	}
 
-	if !paymentResult.Success {
-		return nil, errors.New("payment failed: " + paymentResult.Message)
+	// 2. Price the order from the catalogue, never from the client
+	quote, err := s.QuoteOrder(ctx, input.Items)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkClientTotals(quote, input.SubTotal, input.ShippingCost, input.Total); err != nil {
+		return nil, err
 	}
 
-	userOID, err := primitive.ObjectIDFromHex(userID)
+	// 3. Reserve stock for every line before taking payment
+	if err := s.reserveStock(ctx, quote.Items); err != nil {
+		return nil, err
+	}
+
+	// 4. Process Payment
+	paymentResult, err := s.paymentService.ProcessPayment(quote.Total, "PKR", input.PaymentMethod, input.PaymentDetails)
 	if err != nil {
-		return nil, errors.New("invalid user ID")
+		s.releaseStock(ctx, quote.Items)
+		return nil, err
+	}
+
+	if !paymentResult.Success {
+		s.releaseStock(ctx, quote.Items)
+		return nil, errors.New("payment failed: " + paymentResult.Message)
 	}
 
 	order := &models.Order{
 		ID:              primitive.NewObjectID(),
 		UserID:          userOID,
-		Items:           items,
+		Items:           quote.Items,
 		ShippingAddress: shippingAddress,
-		SubTotal:        subTotal,
-		ShippingCost:    shippingCost,
-		Total:           total,
-		PaymentMethod:   paymentMethod,
-		Status:          "pending", // Initial status
+		SubTotal:        quote.SubTotal,
+		ShippingCost:    quote.ShippingCost,
+		Total:           quote.Total,
+		PaymentMethod:   input.PaymentMethod,
+		Status:          models.OrderStatusPending, // Initial status
 		PaymentStatus:   paymentResult.Status,
+		StockReserved:   true,
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
+		Timeline: []models.OrderEvent{{
+			Status:    models.OrderStatusPending,
+			Actor:     userID,
+			ActorRole: ActorRoleCustomer,
+			Note:      "Order placed",
+			At:        time.Now(),
+		}},
 	}
 
 	if err := s.orderRepo.Create(ctx, order); err != nil {
+		s.releaseStock(ctx, quote.Items)
 		return nil, err
 	}
 
-	// 3. Send Email (Async)
+	// 5. Send Email (Async)
 	go func() {
 		fmt.Printf(" [DEBUG] Starting async email process for OrderID: %s, UserID: %s\n", order.ID.Hex(), userID)
 		// Fetch user to get email
@@ -94,40 +170,18 @@ func (s *OrderService) CreateOrder(ctx context.Context, userID string, items []m
 		fmt.Printf(" [DEBUG] User found: %s. Sending to email: %s\n", user.Name, user.Email)
 
 		var emailItems []models.OrderDetailsItem
-		for _, item := range items {
-			pName := "Product"
-			var price float64
-			var imageURL string
-
-			if item.Product != nil {
-				pName = item.Product.Name
-				price = item.Product.Price
-				imageURL = item.Product.Image
-			} else {
-				// Product not populated, fetch from database
-				prod, err := s.productRepo.GetByID(context.Background(), item.ProductID.Hex())
-				if err == nil && prod != nil {
-					pName = prod.Name
-					price = prod.Price
-					imageURL = prod.Image
-				} else {
-					fmt.Printf(" [WARN] Failed to fetch product for ID %s: %v\n", item.ProductID.Hex(), err)
-					price = 0
-					imageURL = "https://via.placeholder.com/80"
-				}
-			}
-
+		for _, item := range order.Items {
 			emailItems = append(emailItems, models.OrderDetailsItem{
-				Name:     pName,
-				Image:    imageURL,
+				Name:     item.Name,
+				Image:    item.Image,
 				Quantity: item.Quantity,
-				Price:    price,
+				Price:    item.UnitPrice,
 				Size:     item.SelectedSize,
 				Color:    item.SelectedColor,
 			})
 		}
 
-		err = s.emailService.SendOrderConfirmationEmail(user.Email, order.ID.Hex(), emailItems, shippingAddress, total)
+		err = s.emailService.SendOrderConfirmationEmail(user.Email, order.ID.Hex(), emailItems, order.ShippingAddress, order.Total)
 		if err != nil {
 			fmt.Printf(" [ERROR] EmailService returned error: %v\n", err)
 		} else {
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Actor roles recorded on order timeline events
+const (
+	ActorRoleCustomer = "customer"
+	ActorRoleAdmin    = "admin"
+	ActorRoleSystem   = "system"
+)
+
+// orderTransitions lists the statuses each status may move to. Delivered and
+// cancelled are terminal.
+var orderTransitions = map[string][]string{
+	models.OrderStatusPending:    {models.OrderStatusProcessing, models.OrderStatusCancelled},
+	models.OrderStatusProcessing: {models.OrderStatusShipped, models.OrderStatusCancelled},
+	models.OrderStatusShipped:    {models.OrderStatusDelivered},
+}
+
+// ErrOrderStatusConflict is returned when the order changed status while a transition was being applied
+var ErrOrderStatusConflict = errors.New("order status changed concurrently, please retry")
+
+// InvalidTransitionError is returned for a status change the state machine doesn't allow
+type InvalidTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("cannot change order status from %s to %s", e.From, e.To)
+}
+
+// OrderActor identifies who is changing an order
+type OrderActor struct {
+	ID   string
+	Role string
+}
+
+// CanTransition reports whether an order may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// GetUserOrder returns one of the user's own orders, including its timeline
+func (s *OrderService) GetUserOrder(ctx context.Context, userID, orderID string) (*models.Order, error) {
+	order, err := s.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID.Hex() != userID {
+		return nil, mongo.ErrNoDocuments
+	}
+	return order, nil
+}
+
+// GetOrder returns any order by ID
+func (s *OrderService) GetOrder(ctx context.Context, orderID string) (*models.Order, error) {
+	oid, err := primitive.ObjectIDFromHex(orderID)
+	if err != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	return s.orderRepo.FindByID(ctx, oid)
+}
+
+// ListOrders returns the most recent orders, optionally filtered by status
+func (s *OrderService) ListOrders(ctx context.Context, status string, limit int64) ([]models.Order, error) {
+	return s.orderRepo.FindAll(ctx, status, limit)
+}
+
+// CancelOrder lets a customer cancel their own order before it ships
+func (s *OrderService) CancelOrder(ctx context.Context, userID, orderID string) (*models.Order, error) {
+	return s.TransitionStatus(ctx, orderID, models.OrderStatusCancelled, OrderActor{ID: userID, Role: ActorRoleCustomer}, "Cancelled by customer")
+}
+
+// TransitionStatus applies a status change if the state machine allows it,
+// recording it on the order timeline. Customers may only cancel their own
+// orders. Cancelling restocks the order's items.
+func (s *OrderService) TransitionStatus(ctx context.Context, orderID, to string, actor OrderActor, note string) (*models.Order, error) {
+	order, err := s.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if actor.Role == ActorRoleCustomer {
+		if order.UserID.Hex() != actor.ID {
+			return nil, mongo.ErrNoDocuments
+		}
+		if to != models.OrderStatusCancelled {
+			return nil, &InvalidTransitionError{From: order.Status, To: to}
+		}
+	}
+
+	if !CanTransition(order.Status, to) {
+		return nil, &InvalidTransitionError{From: order.Status, To: to}
+	}
+
+	event := models.OrderEvent{
+		Status:    to,
+		From:      order.Status,
+		Actor:     actor.ID,
+		ActorRole: actor.Role,
+		Note:      note,
+		At:        time.Now(),
+	}
+
+	extra := bson.M{}
+	if to == models.OrderStatusDelivered && order.PaymentMethod == "cod" && order.PaymentStatus == "pending" {
+		// Cash on delivery is collected when the parcel is handed over
+		extra["paymentStatus"] = "completed"
+	}
+
+	ok, err := s.orderRepo.TransitionStatus(ctx, order.ID, order.Status, event, extra)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrOrderStatusConflict
+	}
+
+	if to == models.OrderStatusCancelled && order.StockReserved {
+		s.releaseStock(ctx, order.Items)
+	}
+
+	return s.orderRepo.FindByID(ctx, order.ID)
+}
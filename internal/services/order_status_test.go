@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/khusa-mahal/backend/internal/models"
+)
+
+func TestCanTransition(t *testing.T) {
+	statuses := []string{
+		models.OrderStatusPending,
+		models.OrderStatusProcessing,
+		models.OrderStatusShipped,
+		models.OrderStatusDelivered,
+		models.OrderStatusCancelled,
+	}
+	allowed := map[[2]string]bool{
+		{models.OrderStatusPending, models.OrderStatusProcessing}:   true,
+		{models.OrderStatusPending, models.OrderStatusCancelled}:    true,
+		{models.OrderStatusProcessing, models.OrderStatusShipped}:   true,
+		{models.OrderStatusProcessing, models.OrderStatusCancelled}: true,
+		{models.OrderStatusShipped, models.OrderStatusDelivered}:    true,
+	}
+
+	// Every pair not listed is refused, including staying put, skipping
+	// ahead, going back and leaving a terminal status
+	for _, from := range statuses {
+		for _, to := range statuses {
+			want := allowed[[2]string{from, to}]
+			if got := CanTransition(from, to); got != want {
+				t.Errorf("CanTransition(%s, %s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestCanTransitionUnknownStatus(t *testing.T) {
+	tests := []struct {
+		from, to string
+	}{
+		{"refunded", models.OrderStatusCancelled},
+		{models.OrderStatusPending, "refunded"},
+		{"", models.OrderStatusProcessing},
+		{"Pending", models.OrderStatusProcessing},
+	}
+	for _, tt := range tests {
+		if CanTransition(tt.from, tt.to) {
+			t.Errorf("CanTransition(%q, %q) = true, want false", tt.from, tt.to)
+		}
+	}
+}
+
+func TestInvalidTransitionError(t *testing.T) {
+	err := &InvalidTransitionError{From: models.OrderStatusShipped, To: models.OrderStatusCancelled}
+	if got, want := err.Error(), "cannot change order status from shipped to cancelled"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	// maxPage keeps the skip of deep pages well inside an int
+	maxPage = 10000
+)
+
+// Product listing sort orders
+const (
+	SortNewest     = "newest"
+	SortPriceAsc   = "price_asc"
+	SortPriceDesc  = "price_desc"
+	SortRating     = "rating"
+	SortPopularity = "popularity"
+)
+
+// productSorts always end on _id so pages are stable when sort values tie
+var productSorts = map[string]bson.D{
+	SortNewest:     {{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}},
+	SortPriceAsc:   {{Key: "price", Value: 1}, {Key: "_id", Value: 1}},
+	SortPriceDesc:  {{Key: "price", Value: -1}, {Key: "_id", Value: -1}},
+	SortRating:     {{Key: "rating", Value: -1}, {Key: "reviews", Value: -1}, {Key: "_id", Value: -1}},
+	SortPopularity: {{Key: "soldCount", Value: -1}, {Key: "rating", Value: -1}, {Key: "_id", Value: -1}},
+}
+
+// ProductQuery describes a storefront product listing
+type ProductQuery struct {
+	Category string   `json:"category,omitempty"`
+	MinPrice *float64 `json:"minPrice,omitempty"`
+	MaxPrice *float64 `json:"maxPrice,omitempty"`
+	Sizes    []string `json:"sizes,omitempty"`
+	Colors   []string `json:"colors,omitempty"`
+	IsSale   *bool    `json:"isSale,omitempty"`
+	IsNew    *bool    `json:"isNew,omitempty"`
+	InStock  bool     `json:"inStock,omitempty"`
+	Sort     string   `json:"sort"`
+	Page     int      `json:"page"`
+	Limit    int      `json:"limit"`
+}
+
+// ProductListResult is a page of products with pagination details
+type ProductListResult struct {
+	Products   []models.Product `json:"products"`
+	Page       int              `json:"page"`
+	Limit      int              `json:"limit"`
+	Total      int64            `json:"total"`
+	TotalPages int64            `json:"totalPages"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+}
+
+// EncodeCursor returns the opaque cursor for a page
+func EncodeCursor(page int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("page:" + strconv.Itoa(page)))
+}
+
+// DecodeCursor returns the page a cursor from EncodeCursor points at
+func DecodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		if page, err := strconv.Atoi(strings.TrimPrefix(string(raw), "page:")); err == nil && page > 0 {
+			return page, nil
+		}
+	}
+	return 0, &ValidationError{Fields: map[string]string{"cursor": "invalid cursor"}}
+}
+
+// Normalize validates the query and puts it in a canonical form, so equal
+// listings produce the same cache key
+func (q *ProductQuery) Normalize() error {
+	fields := map[string]string{}
+
+	q.Category = strings.TrimSpace(q.Category)
+	q.Sizes = normalizeValues(q.Sizes)
+	q.Colors = normalizeValues(q.Colors)
+
+	q.Sort = strings.ToLower(strings.TrimSpace(q.Sort))
+	if q.Sort == "" {
+		q.Sort = SortNewest
+	}
+	if _, ok := productSorts[q.Sort]; !ok {
+		fields["sort"] = fmt.Sprintf("sort must be one of %s, %s, %s, %s, %s", SortNewest, SortPriceAsc, SortPriceDesc, SortRating, SortPopularity)
+	}
+
+	if q.MinPrice != nil && !isFinite(*q.MinPrice) {
+		fields["minPrice"] = "minPrice must be a number"
+	}
+	if q.MaxPrice != nil && !isFinite(*q.MaxPrice) {
+		fields["maxPrice"] = "maxPrice must be a number"
+	}
+	if q.MinPrice != nil && *q.MinPrice < 0 {
+		fields["minPrice"] = "minPrice cannot be negative"
+	}
+	if q.MinPrice != nil && q.MaxPrice != nil && *q.MinPrice > *q.MaxPrice {
+		fields["maxPrice"] = "maxPrice cannot be lower than minPrice"
+	}
+
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.Page > maxPage {
+		fields["page"] = fmt.Sprintf("page cannot be greater than %d", maxPage)
+	}
+	if q.Limit < 1 {
+		q.Limit = defaultPageSize
+	}
+	if q.Limit > maxPageSize {
+		q.Limit = maxPageSize
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// normalizeValues trims, lowercases, de-duplicates and sorts filter values
+func normalizeValues(values []string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v != "" && !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// CacheKey identifies the normalized query among the listings of its
+// category in the product list cache. Call Normalize first.
+func (q *ProductQuery) CacheKey() (string, error) {
+	data, err := json.Marshal(q)
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+func (q *ProductQuery) filter() bson.M {
+	filter := bson.M{"isArchived": bson.M{"$ne": true}}
+
+	if q.Category != "" {
+		filter["category"] = q.Category
+	}
+
+	if q.MinPrice != nil || q.MaxPrice != nil {
+		price := bson.M{}
+		if q.MinPrice != nil {
+			price["$gte"] = *q.MinPrice
+		}
+		if q.MaxPrice != nil {
+			price["$lte"] = *q.MaxPrice
+		}
+		filter["price"] = price
+	}
+
+	if q.IsSale != nil {
+		filter["isSale"] = flagFilter(*q.IsSale)
+	}
+	if q.IsNew != nil {
+		filter["isNew"] = flagFilter(*q.IsNew)
+	}
+
+	// Product-level options, used as is unless stock has to be checked per variant
+	options := bson.M{}
+	if q.Sizes != nil {
+		options["sizes"] = bson.M{"$in": foldPatterns(q.Sizes)}
+	}
+	if q.Colors != nil {
+		options["colors.name"] = bson.M{"$in": foldPatterns(q.Colors)}
+	}
+
+	if !q.InStock {
+		for k, v := range options {
+			filter[k] = v
+		}
+		return filter
+	}
+
+	// In stock means some variant with a requested size and color has stock.
+	// Products without variants only have product-level stock.
+	variant := bson.M{"stock": bson.M{"$gt": 0}}
+	if q.Sizes != nil {
+		variant["size"] = bson.M{"$in": foldPatterns(q.Sizes)}
+	}
+	if q.Colors != nil {
+		variant["color"] = bson.M{"$in": foldPatterns(q.Colors)}
+	}
+	legacy := bson.M{"variants.0": bson.M{"$exists": false}, "stock": bson.M{"$gt": 0}}
+	for k, v := range options {
+		legacy[k] = v
+	}
+	filter["$or"] = []bson.M{
+		{"variants": bson.M{"$elemMatch": variant}},
+		legacy,
+	}
+	return filter
+}
+
+func flagFilter(value bool) interface{} {
+	if value {
+		return true
+	}
+	return bson.M{"$ne": true}
+}
+
+// foldPatterns matches each value exactly but case-insensitively
+func foldPatterns(values []string) bson.A {
+	patterns := make(bson.A, len(values))
+	for i, v := range values {
+		patterns[i] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(v) + "$", Options: "i"}
+	}
+	return patterns
+}
+
+// ListProducts returns a page of live products matching the query, from the
+// list cache when possible. The query must already be normalized. The bool
+// reports whether the page came from the cache.
+func (s *ProductService) ListProducts(ctx context.Context, q ProductQuery) (*ProductListResult, bool, error) {
+	key, err := q.CacheKey()
+	if err != nil {
+		return nil, false, err
+	}
+
+	page, cached, err := s.cache.FetchProductPage(ctx, models.Slugify(q.Category), key, q.InStock, func(ctx context.Context) (*models.ProductPage, error) {
+		skip := int64((q.Page - 1) * q.Limit)
+		products, total, err := s.repo.FindPage(ctx, q.filter(), productSorts[q.Sort], skip, int64(q.Limit))
+		if err != nil {
+			return nil, err
+		}
+		return &models.ProductPage{Products: products, Total: total}, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return newProductListResult(q, page), cached, nil
+}
+
+func newProductListResult(q ProductQuery, page *models.ProductPage) *ProductListResult {
+	totalPages := (page.Total + int64(q.Limit) - 1) / int64(q.Limit)
+	result := &ProductListResult{
+		Products:   page.Products,
+		Page:       q.Page,
+		Limit:      q.Limit,
+		Total:      page.Total,
+		TotalPages: totalPages,
+	}
+	if int64(q.Page) < totalPages {
+		result.NextCursor = EncodeCursor(q.Page + 1)
+	}
+	return result
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func boolPtr(v bool) *bool {
+	return &v
+}
+
+func TestProductQueryNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		query ProductQuery
+		want  ProductQuery
+	}{
+		{
+			"defaults",
+			ProductQuery{},
+			ProductQuery{Sort: SortNewest, Page: 1, Limit: defaultPageSize},
+		},
+		{
+			"values trimmed, folded, deduplicated and sorted",
+			ProductQuery{Category: " Bridal ", Sizes: []string{"8", " 7", "8", ""}, Colors: []string{"Gold", "gold ", "Maroon"}},
+			ProductQuery{Category: "Bridal", Sizes: []string{"7", "8"}, Colors: []string{"gold", "maroon"}, Sort: SortNewest, Page: 1, Limit: defaultPageSize},
+		},
+		{
+			"blank values dropped",
+			ProductQuery{Sizes: []string{" ", ""}},
+			ProductQuery{Sort: SortNewest, Page: 1, Limit: defaultPageSize},
+		},
+		{
+			"sort ignores case",
+			ProductQuery{Sort: " Price_Asc "},
+			ProductQuery{Sort: SortPriceAsc, Page: 1, Limit: defaultPageSize},
+		},
+		{
+			"limit capped",
+			ProductQuery{Page: 3, Limit: 500},
+			ProductQuery{Sort: SortNewest, Page: 3, Limit: maxPageSize},
+		},
+		{
+			"negative page and limit reset",
+			ProductQuery{Page: -2, Limit: -5},
+			ProductQuery{Sort: SortNewest, Page: 1, Limit: defaultPageSize},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.query.Normalize(); err != nil {
+				t.Fatalf("Normalize = %v", err)
+			}
+			if !reflect.DeepEqual(tt.query, tt.want) {
+				t.Errorf("Normalize = %+v, want %+v", tt.query, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductQueryNormalizeRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		query ProductQuery
+		field string
+	}{
+		{"unknown sort", ProductQuery{Sort: "cheapest"}, "sort"},
+		{"negative minPrice", ProductQuery{MinPrice: floatPtr(-1)}, "minPrice"},
+		{"NaN minPrice", ProductQuery{MinPrice: floatPtr(math.NaN())}, "minPrice"},
+		{"infinite maxPrice", ProductQuery{MaxPrice: floatPtr(math.Inf(1))}, "maxPrice"},
+		{"inverted price range", ProductQuery{MinPrice: floatPtr(5000), MaxPrice: floatPtr(2000)}, "maxPrice"},
+		{"page too deep", ProductQuery{Page: maxPage + 1}, "page"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Normalize()
+
+			var validation *ValidationError
+			if !errors.As(err, &validation) {
+				t.Fatalf("Normalize = %v, want a ValidationError", err)
+			}
+			if _, ok := validation.Fields[tt.field]; !ok {
+				t.Errorf("Normalize fields = %v, want %s", validation.Fields, tt.field)
+			}
+		})
+	}
+}
+
+func TestProductQueryCacheKey(t *testing.T) {
+	key := func(q ProductQuery) string {
+		t.Helper()
+		if err := q.Normalize(); err != nil {
+			t.Fatalf("Normalize = %v", err)
+		}
+		key, err := q.CacheKey()
+		if err != nil {
+			t.Fatalf("CacheKey = %v", err)
+		}
+		return key
+	}
+
+	base := key(ProductQuery{Sizes: []string{"7", "8"}, Colors: []string{"gold"}})
+	if len(base) != 16 {
+		t.Errorf("CacheKey = %q, want 16 hex characters", base)
+	}
+
+	tests := []struct {
+		name  string
+		query ProductQuery
+		same  bool
+	}{
+		{"same filters spelled differently", ProductQuery{Sizes: []string{"8", " 7 ", "7"}, Colors: []string{"Gold"}, Sort: "NEWEST", Page: 1, Limit: 20}, true},
+		{"other page", ProductQuery{Sizes: []string{"7", "8"}, Colors: []string{"gold"}, Page: 2}, false},
+		{"other sort", ProductQuery{Sizes: []string{"7", "8"}, Colors: []string{"gold"}, Sort: SortRating}, false},
+		{"in stock only", ProductQuery{Sizes: []string{"7", "8"}, Colors: []string{"gold"}, InStock: true}, false},
+		{"not on sale differs from unfiltered", ProductQuery{Sizes: []string{"7", "8"}, Colors: []string{"gold"}, IsSale: boolPtr(false)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := key(tt.query) == base; got != tt.same {
+				t.Errorf("same key = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
+
+func TestProductQueryFilter(t *testing.T) {
+	live := bson.M{"$ne": true}
+	fold := func(values ...string) bson.A {
+		patterns := bson.A{}
+		for _, v := range values {
+			patterns = append(patterns, primitive.Regex{Pattern: "^" + v + "$", Options: "i"})
+		}
+		return patterns
+	}
+
+	tests := []struct {
+		name  string
+		query ProductQuery
+		want  bson.M
+	}{
+		{
+			"no filters",
+			ProductQuery{},
+			bson.M{"isArchived": live},
+		},
+		{
+			"category and price range",
+			ProductQuery{Category: "Bridal", MinPrice: floatPtr(2000), MaxPrice: floatPtr(6000)},
+			bson.M{"isArchived": live, "category": "Bridal", "price": bson.M{"$gte": 2000.0, "$lte": 6000.0}},
+		},
+		{
+			"flags",
+			ProductQuery{IsSale: boolPtr(true), IsNew: boolPtr(false)},
+			bson.M{"isArchived": live, "isSale": true, "isNew": bson.M{"$ne": true}},
+		},
+		{
+			"options matched exactly ignoring case",
+			ProductQuery{Sizes: []string{"7"}, Colors: []string{"gold"}},
+			bson.M{"isArchived": live, "sizes": bson.M{"$in": fold("7")}, "colors.name": bson.M{"$in": fold("gold")}},
+		},
+		{
+			"regex syntax matched literally",
+			ProductQuery{Sizes: []string{"7.5"}},
+			bson.M{"isArchived": live, "sizes": bson.M{"$in": fold(`7\.5`)}},
+		},
+		{
+			"in stock checks the variant",
+			ProductQuery{Sizes: []string{"7"}, InStock: true},
+			bson.M{"isArchived": live, "$or": []bson.M{
+				{"variants": bson.M{"$elemMatch": bson.M{"stock": bson.M{"$gt": 0}, "size": bson.M{"$in": fold("7")}}}},
+				{"variants.0": bson.M{"$exists": false}, "stock": bson.M{"$gt": 0}, "sizes": bson.M{"$in": fold("7")}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.filter(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, page := range []int{1, 2, 137, maxPage} {
+		got, err := DecodeCursor(EncodeCursor(page))
+		if err != nil || got != page {
+			t.Errorf("DecodeCursor(EncodeCursor(%d)) = %d, %v", page, got, err)
+		}
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"empty", ""},
+		{"not base64", "%%%"},
+		{"not a page", EncodeCursor(0)[:2]},
+		{"zero page", EncodeCursor(0)},
+		{"negative page", EncodeCursor(-3)},
+		{"plain number", "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := DecodeCursor(tt.cursor)
+
+			var validation *ValidationError
+			if !errors.As(err, &validation) || validation.Fields["cursor"] == "" {
+				t.Errorf("DecodeCursor(%q) = %d, %v; want a cursor ValidationError", tt.cursor, page, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/khusa-mahal/backend/internal/models"
+)
+
+// maxSearchResults is how deep searches can be paged, matching
+// Elasticsearch's default max_result_window
+const maxSearchResults = 10000
+
+// normalizeSearchQuery validates a search and fills in paging defaults.
+// Facet values keep their case because the search index stores them as
+// exact keywords.
+func normalizeSearchQuery(q *models.SearchQuery) error {
+	fields := map[string]string{}
+
+	q.Text = strings.TrimSpace(q.Text)
+	if q.Text == "" {
+		fields["q"] = "search query is required"
+	}
+	q.Category = strings.TrimSpace(q.Category)
+	q.Sizes = trimValues(q.Sizes)
+	q.Colors = trimValues(q.Colors)
+
+	if q.MinPrice != nil && !isFinite(*q.MinPrice) {
+		fields["minPrice"] = "minPrice must be a number"
+	}
+	if q.MaxPrice != nil && !isFinite(*q.MaxPrice) {
+		fields["maxPrice"] = "maxPrice must be a number"
+	}
+	if q.MinPrice != nil && *q.MinPrice < 0 {
+		fields["minPrice"] = "minPrice cannot be negative"
+	}
+	if q.MinPrice != nil && q.MaxPrice != nil && *q.MinPrice > *q.MaxPrice {
+		fields["maxPrice"] = "maxPrice cannot be lower than minPrice"
+	}
+
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.Limit < 1 {
+		q.Limit = defaultPageSize
+	}
+	if q.Limit > maxPageSize {
+		q.Limit = maxPageSize
+	}
+	if q.Page > maxPage || q.Page*q.Limit > maxSearchResults {
+		fields["page"] = fmt.Sprintf("only the first %d results can be paged through", maxSearchResults)
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// trimValues trims and de-duplicates filter values, keeping their order
+func trimValues(values []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v != "" && !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Search runs a faceted product search on the configured search backend
+func (s *ProductService) Search(ctx context.Context, q models.SearchQuery) (*models.SearchResult, error) {
+	if err := normalizeSearchQuery(&q); err != nil {
+		return nil, err
+	}
+
+	result, err := s.search.Search(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	result.Page, result.Limit = q.Page, q.Limit
+	return result, nil
+}
+
+const (
+	maxSuggestions   = 10
+	maxSuggestPrefix = 50 // Longer input is cut to keep keystroke queries cheap
+)
+
+// Suggest returns autocomplete entries for a partly typed search
+func (s *ProductService) Suggest(ctx context.Context, prefix string) ([]models.Suggestion, error) {
+	prefix = strings.TrimSpace(prefix)
+	if runes := []rune(prefix); len(runes) > maxSuggestPrefix {
+		prefix = string(runes[:maxSuggestPrefix])
+	}
+	if prefix == "" {
+		return []models.Suggestion{}, nil
+	}
+
+	return s.search.Suggest(ctx, prefix, maxSuggestions)
+}
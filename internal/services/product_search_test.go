@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/khusa-mahal/backend/internal/models"
+)
+
+func TestNormalizeSearchQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query models.SearchQuery
+		want  models.SearchQuery
+	}{
+		{
+			"defaults",
+			models.SearchQuery{Text: " khussa "},
+			models.SearchQuery{Text: "khussa", Page: 1, Limit: defaultPageSize},
+		},
+		{
+			// Facet values are exact keywords, so their case is kept
+			"values trimmed and deduplicated in order",
+			models.SearchQuery{Text: "khussa", Category: " Bridal ", Sizes: []string{"8", " 7", "8", ""}, Colors: []string{"Gold", "Gold ", "gold"}},
+			models.SearchQuery{Text: "khussa", Category: "Bridal", Sizes: []string{"8", "7"}, Colors: []string{"Gold", "gold"}, Page: 1, Limit: defaultPageSize},
+		},
+		{
+			"limit capped",
+			models.SearchQuery{Text: "khussa", Page: 2, Limit: 500},
+			models.SearchQuery{Text: "khussa", Page: 2, Limit: maxPageSize},
+		},
+		{
+			"last page of the result window",
+			models.SearchQuery{Text: "khussa", Page: maxSearchResults / maxPageSize, Limit: maxPageSize},
+			models.SearchQuery{Text: "khussa", Page: maxSearchResults / maxPageSize, Limit: maxPageSize},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := normalizeSearchQuery(&tt.query); err != nil {
+				t.Fatalf("normalizeSearchQuery = %v", err)
+			}
+			if !reflect.DeepEqual(tt.query, tt.want) {
+				t.Errorf("normalizeSearchQuery = %+v, want %+v", tt.query, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeSearchQueryRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		query models.SearchQuery
+		field string
+	}{
+		{"missing text", models.SearchQuery{Text: "  "}, "q"},
+		{"negative minPrice", models.SearchQuery{Text: "khussa", MinPrice: floatPtr(-1)}, "minPrice"},
+		{"NaN minPrice", models.SearchQuery{Text: "khussa", MinPrice: floatPtr(math.NaN())}, "minPrice"},
+		{"infinite maxPrice", models.SearchQuery{Text: "khussa", MaxPrice: floatPtr(math.Inf(1))}, "maxPrice"},
+		{"negative infinite minPrice", models.SearchQuery{Text: "khussa", MinPrice: floatPtr(math.Inf(-1))}, "minPrice"},
+		{"inverted price range", models.SearchQuery{Text: "khussa", MinPrice: floatPtr(5000), MaxPrice: floatPtr(2000)}, "maxPrice"},
+		{"past the result window", models.SearchQuery{Text: "khussa", Page: maxSearchResults/maxPageSize + 1, Limit: maxPageSize}, "page"},
+		{"past the result window at the default limit", models.SearchQuery{Text: "khussa", Page: 501}, "page"},
+		// Would overflow the offset if it were multiplied out
+		{"huge page", models.SearchQuery{Text: "khussa", Page: math.MaxInt}, "page"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := normalizeSearchQuery(&tt.query)
+
+			var validation *ValidationError
+			if !errors.As(err, &validation) {
+				t.Fatalf("normalizeSearchQuery = %v, want a ValidationError", err)
+			}
+			if _, ok := validation.Fields[tt.field]; !ok {
+				t.Errorf("normalizeSearchQuery fields = %v, want %s", validation.Fields, tt.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,413 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"github.com/khusa-mahal/backend/internal/repository/mongodb"
+	"github.com/khusa-mahal/backend/internal/repository/redis"
+	"github.com/khusa-mahal/backend/internal/search"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrProductUpdateConflict is returned when a product kept changing while an
+// edit that rewrites its stock was being applied
+var ErrProductUpdateConflict = errors.New("product changed concurrently, please retry")
+
+// maxUpdateAttempts bounds how often UpdateProduct rereads a product that
+// changed under it
+const maxUpdateAttempts = 3
+
+// ProductService handles catalogue writes and keeps the Redis cache and the
+// search backend in step with MongoDB.
+type ProductService struct {
+	repo       *mongodb.ProductRepository
+	categories *mongodb.CategoryRepository
+	cache      *redis.Cache
+	search     search.Backend
+}
+
+func NewProductService(repo *mongodb.ProductRepository, categories *mongodb.CategoryRepository, cache *redis.Cache, search search.Backend) *ProductService {
+	return &ProductService{
+		repo:       repo,
+		categories: categories,
+		cache:      cache,
+		search:     search,
+	}
+}
+
+// ProductInput is the editable part of a product
+type ProductInput struct {
+	Name             string               `json:"name"`
+	NameUrdu         string               `json:"nameUrdu"`
+	Category         string               `json:"category"` // Category name or slug
+	Price            float64              `json:"price"`
+	OriginalPrice    *float64             `json:"originalPrice"`
+	Discount         *float64             `json:"discount"`
+	Image            string               `json:"image"`
+	Images           []string             `json:"images"`
+	CombinationImage string               `json:"combinationImage"`
+	WornImage        string               `json:"wornImage"`
+	Description      string               `json:"description"`
+	IsNew            bool                 `json:"isNew"`
+	IsSale           bool                 `json:"isSale"`
+	Sizes            []string             `json:"sizes"`
+	Colors           []models.ColorOption `json:"colors"`
+	Variants         []models.Variant     `json:"variants"` // Optional; stock is set to exactly these values
+}
+
+// ValidationError lists every invalid field in a request
+type ValidationError struct {
+	Fields map[string]string `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	return "validation failed"
+}
+
+// SyncReport describes whether the search index was updated after a
+// successful database write. A failed update leaves stale results behind until
+// the product is written again. Caches are evicted by the repository itself on
+// every write.
+type SyncReport struct {
+	Synced      bool   `json:"synced"`
+	SearchError string `json:"searchError,omitempty"`
+}
+
+func (in *ProductInput) normalize() {
+	in.Name = strings.TrimSpace(in.Name)
+	in.NameUrdu = strings.TrimSpace(in.NameUrdu)
+	in.Category = strings.TrimSpace(in.Category)
+	in.Image = strings.TrimSpace(in.Image)
+	in.Description = strings.TrimSpace(in.Description)
+
+	sizes := make([]string, 0, len(in.Sizes))
+	for _, size := range in.Sizes {
+		if size = strings.TrimSpace(size); size != "" {
+			sizes = append(sizes, size)
+		}
+	}
+	in.Sizes = sizes
+
+	for i := range in.Colors {
+		in.Colors[i].Name = strings.TrimSpace(in.Colors[i].Name)
+	}
+}
+
+func (in *ProductInput) validate() error {
+	fields := map[string]string{}
+
+	if in.Name == "" {
+		fields["name"] = "name is required"
+	}
+	if in.Category == "" {
+		fields["category"] = "category is required"
+	}
+	if in.Price <= 0 {
+		fields["price"] = "price must be greater than zero"
+	}
+	if in.OriginalPrice != nil && *in.OriginalPrice < in.Price {
+		fields["originalPrice"] = "originalPrice cannot be lower than price"
+	}
+	if in.Discount != nil && (*in.Discount < 0 || *in.Discount >= 100) {
+		fields["discount"] = "discount must be between 0 and 100"
+	}
+	if in.Image == "" {
+		fields["image"] = "image is required"
+	}
+	if len(in.Sizes) == 0 {
+		fields["sizes"] = "at least one size is required"
+	}
+	if len(in.Colors) == 0 {
+		fields["colors"] = "at least one color is required"
+	}
+	for _, color := range in.Colors {
+		if color.Name == "" {
+			fields["colors"] = "every color needs a name"
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, v := range in.Variants {
+		key := strings.ToLower(v.Size + "\x00" + v.Color)
+		switch {
+		case !containsFold(in.Sizes, v.Size):
+			fields["variants"] = fmt.Sprintf("variant size %q is not in sizes", v.Size)
+		case !hasColor(in.Colors, v.Color):
+			fields["variants"] = fmt.Sprintf("variant color %q is not in colors", v.Color)
+		case v.Stock < 0:
+			fields["variants"] = "variant stock cannot be negative"
+		case seen[key]:
+			fields["variants"] = fmt.Sprintf("duplicate variant %s/%s", v.Size, v.Color)
+		}
+		seen[key] = true
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// buildVariants returns one variant per size and color, taking stock from
+// given (or from existing when given is nil) and zero for new combinations.
+func buildVariants(sizes []string, colors []models.ColorOption, given, existing []models.Variant) ([]models.Variant, int) {
+	source := given
+	if source == nil {
+		source = existing
+	}
+
+	variants := make([]models.Variant, 0, len(sizes)*len(colors))
+	total := 0
+	for _, size := range sizes {
+		for _, color := range colors {
+			stock := 0
+			for _, v := range source {
+				if strings.EqualFold(v.Size, size) && strings.EqualFold(v.Color, color.Name) {
+					stock = v.Stock
+					break
+				}
+			}
+			variants = append(variants, models.Variant{
+				SKU:   models.VariantSKU(size, color.Name),
+				Size:  size,
+				Color: color.Name,
+				Stock: stock,
+			})
+			total += stock
+		}
+	}
+	return variants, total
+}
+
+// resolveCategory looks up the category named (or slugged) in the input
+func (s *ProductService) resolveCategory(ctx context.Context, name string) (*models.Category, error) {
+	category, err := s.categories.FindBySlug(ctx, models.Slugify(name))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, &ValidationError{Fields: map[string]string{"category": fmt.Sprintf("unknown category %q", name)}}
+	}
+	return category, err
+}
+
+// ListAll returns every product, including archived ones
+func (s *ProductService) ListAll(ctx context.Context) ([]models.Product, error) {
+	return s.repo.GetAll(ctx, bson.M{})
+}
+
+// CreateProduct validates and stores a new product, then caches and indexes it
+func (s *ProductService) CreateProduct(ctx context.Context, input ProductInput) (*models.Product, *SyncReport, error) {
+	input.normalize()
+	if err := input.validate(); err != nil {
+		return nil, nil, err
+	}
+	category, err := s.resolveCategory(ctx, input.Category)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	variants, stock := buildVariants(input.Sizes, input.Colors, input.Variants, nil)
+	product := &models.Product{
+		Name:             input.Name,
+		NameUrdu:         input.NameUrdu,
+		Category:         category.Name,
+		CategoryID:       category.ID,
+		Price:            input.Price,
+		OriginalPrice:    input.OriginalPrice,
+		Discount:         input.Discount,
+		Image:            input.Image,
+		Images:           input.Images,
+		CombinationImage: input.CombinationImage,
+		WornImage:        input.WornImage,
+		Description:      input.Description,
+		IsNew:            input.IsNew,
+		IsSale:           input.IsSale,
+		Sizes:            input.Sizes,
+		Colors:           input.Colors,
+		Variants:         variants,
+		Stock:            stock,
+	}
+
+	if err := s.repo.Create(ctx, product); err != nil {
+		return nil, nil, err
+	}
+
+	report := s.SyncProduct(ctx, product.ID.Hex())
+	return product, report, nil
+}
+
+// UpdateProduct replaces a product's editable fields. Stock is only written
+// when variants are supplied or sizes/colors change, so a plain edit never
+// overwrites reservations made in the meantime. When it is, the write only
+// applies if the product is unchanged since it was read, and is retried
+// against a fresh copy otherwise.
+func (s *ProductService) UpdateProduct(ctx context.Context, id string, input ProductInput) (*models.Product, *SyncReport, error) {
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return nil, nil, mongo.ErrNoDocuments
+	}
+
+	existing, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	input.normalize()
+	if err := input.validate(); err != nil {
+		return nil, nil, err
+	}
+	category, err := s.resolveCategory(ctx, input.Category)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		updated, err := s.updateProduct(ctx, existing, input, category)
+		if err != nil {
+			return nil, nil, err
+		}
+		if updated {
+			break
+		}
+		if attempt == maxUpdateAttempts {
+			return nil, nil, ErrProductUpdateConflict
+		}
+		if existing, err = s.repo.GetByID(ctx, id); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	report := s.SyncProduct(ctx, id)
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return product, report, nil
+}
+
+// updateProduct writes input over existing. It reports false when the write
+// depended on existing's stock and the product changed since it was read.
+func (s *ProductService) updateProduct(ctx context.Context, existing *models.Product, input ProductInput, category *models.Category) (bool, error) {
+	fields := bson.M{
+		"name":             input.Name,
+		"nameUrdu":         input.NameUrdu,
+		"category":         category.Name,
+		"categoryId":       category.ID,
+		"price":            input.Price,
+		"originalPrice":    input.OriginalPrice,
+		"discount":         input.Discount,
+		"image":            input.Image,
+		"images":           input.Images,
+		"combinationImage": input.CombinationImage,
+		"wornImage":        input.WornImage,
+		"description":      input.Description,
+		"isNew":            input.IsNew,
+		"isSale":           input.IsSale,
+		"sizes":            input.Sizes,
+		"colors":           input.Colors,
+	}
+
+	// Legacy products without variants keep product-level stock until an
+	// admin supplies variants for them.
+	hasVariants := len(existing.Variants) > 0 || input.Variants != nil
+	if hasVariants && (input.Variants != nil || !sameOptions(existing, input)) {
+		variants, stock := buildVariants(input.Sizes, input.Colors, input.Variants, existing.Variants)
+		fields["variants"] = variants
+		fields["stock"] = stock
+		return s.repo.UpdateFieldsIfUnchanged(ctx, existing.ID, existing.UpdatedAt, fields)
+	}
+
+	return true, s.repo.UpdateFields(ctx, existing.ID, fields)
+}
+
+func sameOptions(product *models.Product, input ProductInput) bool {
+	if len(product.Sizes) != len(input.Sizes) || len(product.Colors) != len(input.Colors) {
+		return false
+	}
+	for i := range product.Sizes {
+		if product.Sizes[i] != input.Sizes[i] {
+			return false
+		}
+	}
+	for i := range product.Colors {
+		if product.Colors[i].Name != input.Colors[i].Name {
+			return false
+		}
+	}
+	return true
+}
+
+// SetArchived hides (or restores) a product from the storefront and search
+// without deleting it, so existing orders and carts still resolve.
+func (s *ProductService) SetArchived(ctx context.Context, id string, archived bool) (*models.Product, *SyncReport, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, nil, mongo.ErrNoDocuments
+	}
+
+	if err := s.repo.UpdateFields(ctx, oid, bson.M{"isArchived": archived}); err != nil {
+		return nil, nil, err
+	}
+
+	report := s.SyncProduct(ctx, id)
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return product, report, nil
+}
+
+// DeleteProduct permanently removes a product and its cache and search entries
+func (s *ProductService) DeleteProduct(ctx context.Context, id string) (*SyncReport, error) {
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.SyncProduct(ctx, id), nil
+}
+
+// SyncProduct brings the product's search document in line with MongoDB:
+// indexed when live, removed when archived or deleted. It is safe to call
+// after any product write.
+func (s *ProductService) SyncProduct(ctx context.Context, id string) *SyncReport {
+	return s.SyncProducts(ctx, []string{id})
+}
+
+// SyncProducts does what SyncProduct does for several products
+func (s *ProductService) SyncProducts(ctx context.Context, ids []string) *SyncReport {
+	report := &SyncReport{}
+
+	var searchErrs []string
+	for _, id := range ids {
+		if err := s.syncSearch(ctx, id); err != nil {
+			searchErrs = append(searchErrs, err.Error())
+		}
+	}
+	report.SearchError = strings.Join(searchErrs, "; ")
+
+	report.Synced = report.SearchError == ""
+	if !report.Synced {
+		fmt.Printf("⚠️  Products %v saved but not synced to search: %s\n", ids, report.SearchError)
+	}
+	return report
+}
+
+func (s *ProductService) syncSearch(ctx context.Context, id string) error {
+	product, err := s.repo.GetByID(ctx, id)
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments) || (err == nil && product.IsArchived):
+		if err := s.search.DeleteProduct(ctx, id); err != nil && !errors.Is(err, search.ErrNotFound) {
+			return err
+		}
+		return nil
+	case err != nil:
+		return err
+	default:
+		return s.search.IndexProduct(ctx, product)
+	}
+}
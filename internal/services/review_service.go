@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"github.com/khusa-mahal/backend/internal/repository/mongodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const maxReviewCommentLength = 2000
+
+var (
+	// ErrNotVerifiedPurchase is returned when the user has no delivered order containing the product
+	ErrNotVerifiedPurchase = errors.New("only customers who received this product can review it")
+	// ErrAlreadyReviewed is returned for a second review of the same product
+	ErrAlreadyReviewed = errors.New("you have already reviewed this product")
+)
+
+// ReviewService stores verified-purchase reviews and keeps each product's
+// rating and review count in step with them
+type ReviewService struct {
+	reviewRepo  *mongodb.ReviewRepository
+	orderRepo   *mongodb.OrderRepository
+	userRepo    *mongodb.UserRepository
+	productRepo *mongodb.ProductRepository
+	products    *ProductService
+}
+
+func NewReviewService(reviewRepo *mongodb.ReviewRepository, orderRepo *mongodb.OrderRepository, userRepo *mongodb.UserRepository, productRepo *mongodb.ProductRepository, products *ProductService) *ReviewService {
+	return &ReviewService{
+		reviewRepo:  reviewRepo,
+		orderRepo:   orderRepo,
+		userRepo:    userRepo,
+		productRepo: productRepo,
+		products:    products,
+	}
+}
+
+type ReviewInput struct {
+	Rating  int    `json:"rating"`
+	Comment string `json:"comment"`
+}
+
+// ReviewPage is one page of a product's reviews
+type ReviewPage struct {
+	Reviews    []models.Review `json:"reviews"`
+	Page       int             `json:"page"`
+	Limit      int             `json:"limit"`
+	Total      int64           `json:"total"`
+	TotalPages int64           `json:"totalPages"`
+}
+
+// CreateReview adds the user's review of a product they have received and
+// recomputes the product's rating
+func (s *ReviewService) CreateReview(ctx context.Context, userID, productID string, input ReviewInput) (*models.Review, error) {
+	input.Comment = strings.TrimSpace(input.Comment)
+	fields := map[string]string{}
+	if input.Rating < 1 || input.Rating > 5 {
+		fields["rating"] = "rating must be between 1 and 5"
+	}
+	if len(input.Comment) > maxReviewCommentLength {
+		fields["comment"] = fmt.Sprintf("comment must be at most %d characters", maxReviewCommentLength)
+	}
+	if len(fields) > 0 {
+		return nil, &ValidationError{Fields: fields}
+	}
+
+	userOID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	product, err := s.liveProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivered, err := s.orderRepo.HasDeliveredProduct(ctx, userOID, product.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !delivered {
+		return nil, ErrNotVerifiedPurchase
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userOID)
+	if err != nil {
+		return nil, err
+	}
+
+	review := &models.Review{
+		ProductID: product.ID,
+		UserID:    userOID,
+		UserName:  user.Name,
+		Rating:    input.Rating,
+		Comment:   input.Comment,
+	}
+	if err := s.reviewRepo.Create(ctx, review); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrAlreadyReviewed
+		}
+		return nil, err
+	}
+
+	if err := s.refreshRating(ctx, product.ID); err != nil {
+		fmt.Printf("⚠️  Review saved but rating for product %s not updated: %v\n", product.ID.Hex(), err)
+	}
+
+	return review, nil
+}
+
+// liveProduct returns the product, or mongo.ErrNoDocuments if the ID is
+// malformed or the product is missing or archived. Other errors pass through.
+func (s *ReviewService) liveProduct(ctx context.Context, id string) (*models.Product, error) {
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	product, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product.IsArchived {
+		return nil, mongo.ErrNoDocuments
+	}
+	return product, nil
+}
+
+// ListReviews returns a page of reviews for a live product, newest first
+func (s *ReviewService) ListReviews(ctx context.Context, productID string, page, limit int) (*ReviewPage, error) {
+	product, err := s.liveProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews, total, err := s.reviewRepo.FindByProduct(ctx, product.ID, int64((page-1)*limit), int64(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReviewPage{
+		Reviews:    reviews,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}, nil
+}
+
+// refreshRating recomputes the product's rating from its reviews, then
+// evicts it from the cache and reindexes it
+func (s *ReviewService) refreshRating(ctx context.Context, productID primitive.ObjectID) error {
+	average, count, err := s.reviewRepo.Stats(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	rating := math.Round(average*10) / 10
+	if err := s.productRepo.UpdateRating(ctx, productID, rating, count); err != nil {
+		return err
+	}
+
+	s.products.SyncProduct(ctx, productID.Hex())
+	return nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"github.com/khusa-mahal/backend/internal/repository/mongodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultReportLimit = 20
+	maxReportLimit     = 100
+	maxReportRange     = 366 * 24 * time.Hour
+	searchLogTimeout   = 2 * time.Second
+)
+
+// SearchAnalyticsService records storefront searches and result clicks and
+// reports on them, so merchandising can see what customers look for
+type SearchAnalyticsService struct {
+	repo *mongodb.SearchLogRepository
+}
+
+func NewSearchAnalyticsService(repo *mongodb.SearchLogRepository) *SearchAnalyticsService {
+	return &SearchAnalyticsService{repo: repo}
+}
+
+// SearchEvent describes a search to record
+type SearchEvent struct {
+	Query       string
+	Filtered    bool // Any facet filter was applied
+	ResultCount int64
+	UserID      string // Empty for guests
+	SessionID   string
+}
+
+// SearchReport summarizes searches over a date range
+type SearchReport struct {
+	From              time.Time           `json:"from"`
+	To                time.Time           `json:"to"`
+	Searches          int64               `json:"searches"`
+	Clicked           int64               `json:"clicked"`
+	ClickThroughRate  float64             `json:"clickThroughRate"`
+	ZeroResults       int64               `json:"zeroResults"`
+	ZeroResultRate    float64             `json:"zeroResultRate"`
+	TopQueries        []mongodb.QueryStat `json:"topQueries"`
+	ZeroResultQueries []mongodb.QueryStat `json:"zeroResultQueries"`
+}
+
+// normalizeTerm groups spellings that differ only in case and spacing
+func normalizeTerm(query string) string {
+	return strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
+
+// Record logs a search and returns the log entry's ID, which the storefront
+// sends back when a result is clicked. The entry is written before the ID is
+// handed out so a quick click always finds it; if it can't be written the
+// search goes unlogged and an empty ID is returned.
+func (s *SearchAnalyticsService) Record(ctx context.Context, event SearchEvent) string {
+	entry := &models.SearchLog{
+		ID:          primitive.NewObjectID(),
+		Query:       strings.TrimSpace(event.Query),
+		Term:        normalizeTerm(event.Query),
+		Filtered:    event.Filtered,
+		ResultCount: event.ResultCount,
+		SessionID:   event.SessionID,
+		CreatedAt:   time.Now(),
+	}
+	if userID, err := primitive.ObjectIDFromHex(event.UserID); err == nil {
+		entry.UserID = &userID
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, searchLogTimeout)
+	defer cancel()
+	if err := s.repo.Create(ctx, entry); err != nil {
+		fmt.Printf("⚠️  Failed to log search %q: %v\n", entry.Term, err)
+		return ""
+	}
+	return entry.ID.Hex()
+}
+
+// RecordClick attributes a product click to a logged search
+func (s *SearchAnalyticsService) RecordClick(ctx context.Context, queryID, productID string) error {
+	fields := map[string]string{}
+	qid, err := primitive.ObjectIDFromHex(queryID)
+	if err != nil {
+		fields["queryId"] = "queryId is invalid"
+	}
+	pid, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		fields["productId"] = "productId is invalid"
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+
+	return s.repo.RecordClick(ctx, qid, pid)
+}
+
+func rate(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
+
+// Report summarizes searches in [from, to): totals, click-through and
+// zero-result rates, and the top limit terms overall and among searches
+// that found nothing
+func (s *SearchAnalyticsService) Report(ctx context.Context, from, to time.Time, limit int) (*SearchReport, error) {
+	if !from.Before(to) {
+		return nil, &ValidationError{Fields: map[string]string{"to": "to must be after from"}}
+	}
+	if to.Sub(from) > maxReportRange {
+		return nil, &ValidationError{Fields: map[string]string{"from": "reports cover at most one year"}}
+	}
+	if limit < 1 {
+		limit = defaultReportLimit
+	}
+	if limit > maxReportLimit {
+		limit = maxReportLimit
+	}
+
+	totals, err := s.repo.Totals(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	top, err := s.repo.TopQueries(ctx, from, to, int64(limit))
+	if err != nil {
+		return nil, err
+	}
+	zero, err := s.repo.ZeroResultQueries(ctx, from, to, int64(limit))
+	if err != nil {
+		return nil, err
+	}
+	for _, stats := range [][]mongodb.QueryStat{top, zero} {
+		for i := range stats {
+			stats[i].ClickThrough = rate(stats[i].Clicked, stats[i].Searches)
+		}
+	}
+
+	return &SearchReport{
+		From:              from,
+		To:                to,
+		Searches:          totals.Searches,
+		Clicked:           totals.Clicked,
+		ClickThroughRate:  rate(totals.Clicked, totals.Searches),
+		ZeroResults:       totals.ZeroResults,
+		ZeroResultRate:    rate(totals.ZeroResults, totals.Searches),
+		TopQueries:        top,
+		ZeroResultQueries: zero,
+	}, nil
+}
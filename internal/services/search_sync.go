@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"github.com/khusa-mahal/backend/internal/repository/elasticsearch"
+	"github.com/khusa-mahal/backend/internal/repository/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// searchSyncName identifies the syncer's resume token in sync_state
+const searchSyncName = "products-search"
+
+const maxSyncBackoff = time.Minute
+
+// The instance running the syncer holds a lease on its sync_state document,
+// renewing it well before it runs out. The others stand by and take over
+// once it stops being renewed.
+const (
+	syncLeaseTTL   = 30 * time.Second
+	syncLeaseRenew = 10 * time.Second
+)
+
+// Change stream error codes after which the saved position can't be resumed
+const (
+	errCodeChangeStreamFatal       = 280
+	errCodeChangeStreamHistoryLost = 286
+)
+
+// Search sync states
+const (
+	SyncStarting = "starting"
+	SyncRunning  = "running"
+	SyncRetrying = "retrying"
+	SyncStandby  = "standby" // Another instance holds the lease
+)
+
+// SearchSyncStatus is the syncer's health report
+type SearchSyncStatus struct {
+	Status        string     `json:"status"`
+	EventsApplied int64      `json:"eventsApplied"` // Since the server started
+	LastEventAt   *time.Time `json:"lastEventAt,omitempty"`
+	LastAppliedAt *time.Time `json:"lastAppliedAt,omitempty"`
+	// LagSeconds is how long the last event waited to be applied. While
+	// retrying it is the time since the syncer stopped applying events.
+	LagSeconds float64 `json:"lagSeconds"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// SearchSyncer tails the products change stream and mirrors every write into
+// Elasticsearch, so edits made outside the API (scripts, the Mongo shell,
+// stock reservations) reach search too. Its position is saved after each
+// event, so a restart resumes where it stopped. Only the instance holding the
+// sync lease tails the stream.
+type SearchSyncer struct {
+	repo   *mongodb.ProductRepository
+	state  *mongodb.SyncStateRepository
+	search *elasticsearch.SearchService
+	owner  string // Identifies this instance on the lease
+
+	mu         sync.RWMutex
+	status     SearchSyncStatus
+	errorSince time.Time
+}
+
+func NewSearchSyncer(repo *mongodb.ProductRepository, state *mongodb.SyncStateRepository, search *elasticsearch.SearchService) *SearchSyncer {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+
+	return &SearchSyncer{
+		repo:   repo,
+		state:  state,
+		search: search,
+		owner:  hex.EncodeToString(id),
+		status: SearchSyncStatus{Status: SyncStarting},
+	}
+}
+
+type productChange struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		ID primitive.ObjectID `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument *models.Product     `bson:"fullDocument"`
+	ClusterTime  primitive.Timestamp `bson:"clusterTime"`
+}
+
+// Run syncs until ctx is cancelled, reopening the stream with backoff after
+// errors. While another instance holds the lease it stands by, trying to take
+// the lease over every renewal interval.
+func (s *SearchSyncer) Run(ctx context.Context) {
+	defer s.release()
+
+	backoff := time.Second
+	for {
+		held, err := s.state.Acquire(ctx, searchSyncName, s.owner, syncLeaseTTL)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil && !held {
+			s.standby()
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(syncLeaseRenew):
+			}
+			continue
+		}
+
+		applied := false
+		if err == nil {
+			applied, err = s.lead(ctx)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if applied {
+			backoff = time.Second
+		}
+
+		s.failed(err)
+		fmt.Printf("⚠️  Search sync stopped, retrying in %s: %v\n", backoff, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < maxSyncBackoff {
+			backoff *= 2
+		}
+	}
+}
+
+// lead tails the change stream while renewing the lease, and stops tailing as
+// soon as the lease can't be renewed
+func (s *SearchSyncer) lead(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	go func() {
+		ticker := time.NewTicker(syncLeaseRenew)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				held, err := s.state.Acquire(ctx, searchSyncName, s.owner, syncLeaseTTL)
+				if err == nil && !held {
+					err = mongodb.ErrLeaseLost
+				}
+				if err != nil {
+					cancel(err)
+					return
+				}
+			}
+		}
+	}()
+
+	applied, err := s.tail(ctx)
+	if cause := context.Cause(ctx); cause != nil && !errors.Is(cause, context.Canceled) {
+		err = cause
+	}
+	return applied, err
+}
+
+// release gives up the lease when the syncer stops, so a standby instance
+// doesn't have to wait for it to run out
+func (s *SearchSyncer) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.state.Release(ctx, searchSyncName, s.owner); err != nil {
+		fmt.Printf("⚠️  Failed to release the search sync lease: %v\n", err)
+	}
+}
+
+// tail follows the change stream until it fails, reporting whether any
+// event was applied
+func (s *SearchSyncer) tail(ctx context.Context) (bool, error) {
+	state, err := s.state.Get(ctx, searchSyncName)
+	if err != nil {
+		return false, err
+	}
+	var token bson.Raw
+	if state != nil {
+		token = state.ResumeToken
+	}
+
+	stream, err := s.repo.Watch(ctx, token)
+	if err != nil {
+		if token != nil && isUnresumable(err) {
+			s.restart(ctx)
+		}
+		return false, err
+	}
+	defer stream.Close(context.Background())
+	s.running()
+
+	applied := false
+	for stream.Next(ctx) {
+		var event productChange
+		if err := stream.Decode(&event); err != nil {
+			return applied, err
+		}
+
+		if event.OperationType == "invalidate" {
+			// The collection was dropped or renamed; the stream can't resume past this
+			s.restart(ctx)
+			return applied, errors.New("products change stream invalidated")
+		}
+
+		// The token is only saved once the event is applied, so a failed
+		// event is retried when the stream reopens
+		if err := s.apply(ctx, &event); err != nil {
+			return applied, err
+		}
+		eventAt := time.Unix(int64(event.ClusterTime.T), 0)
+		if err := s.state.Save(ctx, searchSyncName, s.owner, stream.ResumeToken(), eventAt); err != nil {
+			return applied, err
+		}
+		s.applied(eventAt)
+		applied = true
+	}
+
+	if err := stream.Err(); err != nil {
+		if isUnresumable(err) {
+			s.restart(ctx)
+		}
+		return applied, err
+	}
+	return applied, ctx.Err()
+}
+
+func (s *SearchSyncer) apply(ctx context.Context, event *productChange) error {
+	switch event.OperationType {
+	case "insert", "update", "replace":
+		// A nil document means the product was deleted before the lookup
+		if event.FullDocument != nil && !event.FullDocument.IsArchived {
+			return s.search.IndexProduct(ctx, event.FullDocument)
+		}
+		return s.remove(ctx, event.DocumentKey.ID)
+	case "delete":
+		return s.remove(ctx, event.DocumentKey.ID)
+	}
+	return nil
+}
+
+func (s *SearchSyncer) remove(ctx context.Context, id primitive.ObjectID) error {
+	if err := s.search.DeleteProduct(ctx, id.Hex()); err != nil && !errors.Is(err, elasticsearch.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// restart drops a position the stream can no longer resume from. Events in
+// the gap are lost, so the index needs a full rebuild with cmd/reindex.
+func (s *SearchSyncer) restart(ctx context.Context) {
+	fmt.Println("⚠️  Search sync position lost; starting from now. Run cmd/reindex to catch up.")
+	if err := s.state.Reset(ctx, searchSyncName, s.owner); err != nil {
+		fmt.Printf("⚠️  Failed to reset search sync position: %v\n", err)
+	}
+}
+
+func isUnresumable(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) &&
+		(serverErr.HasErrorCode(errCodeChangeStreamHistoryLost) || serverErr.HasErrorCode(errCodeChangeStreamFatal))
+}
+
+func (s *SearchSyncer) running() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Status = SyncRunning
+	s.status.Error = ""
+	s.errorSince = time.Time{}
+}
+
+func (s *SearchSyncer) standby() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Status = SyncStandby
+	s.status.Error = ""
+	s.errorSince = time.Time{}
+}
+
+func (s *SearchSyncer) applied(eventAt time.Time) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.EventsApplied++
+	s.status.LastEventAt = &eventAt
+	s.status.LastAppliedAt = &now
+	s.status.LagSeconds = now.Sub(eventAt).Seconds()
+}
+
+func (s *SearchSyncer) failed(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Status = SyncRetrying
+	if err != nil {
+		s.status.Error = err.Error()
+	}
+	if s.errorSince.IsZero() {
+		s.errorSince = time.Now()
+	}
+}
+
+// Status returns a snapshot of the syncer's progress
+func (s *SearchSyncer) Status() SearchSyncStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := s.status
+	if status.Status == SyncRetrying {
+		status.LagSeconds = time.Since(s.errorSince).Seconds()
+	}
+	return status
+}
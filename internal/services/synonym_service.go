@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"github.com/khusa-mahal/backend/internal/repository/elasticsearch"
+	"github.com/khusa-mahal/backend/internal/repository/mongodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const maxSynonymTermLength = 50
+
+// defaultSynonyms seed an empty synonym list: common Roman-Urdu spellings and
+// the Urdu-script words customers use for the same products
+var defaultSynonyms = [][]string{
+	{"khussa", "khusa", "khosa", "khussay", "khusay", "کھسہ", "کھسے"},
+	{"jutti", "juti", "jooti", "juttis", "جوتی"},
+	{"kolhapuri", "kolapuri", "کولہاپوری"},
+	{"peshawari", "peshawri", "پشاوری"},
+	{"bridal", "dulhan", "دلہن"},
+	{"golden", "gold", "sunehri", "سنہری"},
+}
+
+// SynonymService manages the search synonym list in MongoDB and pushes it to
+// Elasticsearch after every change
+type SynonymService struct {
+	repo   *mongodb.SynonymRepository
+	search *elasticsearch.SearchService // nil when Elasticsearch is unavailable
+}
+
+func NewSynonymService(repo *mongodb.SynonymRepository, search *elasticsearch.SearchService) *SynonymService {
+	return &SynonymService{
+		repo:   repo,
+		search: search,
+	}
+}
+
+// normalizeTerms lowercases and de-duplicates a group's terms and rejects
+// anything that would break the synonym rule syntax
+func normalizeTerms(terms []string) ([]string, error) {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, term := range terms {
+		term = strings.ToLower(strings.Join(strings.Fields(term), " "))
+		if term == "" || seen[term] {
+			continue
+		}
+		if strings.ContainsAny(term, ",=>#\\") {
+			return nil, &ValidationError{Fields: map[string]string{"terms": fmt.Sprintf("term %q contains a reserved character", term)}}
+		}
+		if utf8.RuneCountInString(term) > maxSynonymTermLength {
+			return nil, &ValidationError{Fields: map[string]string{"terms": fmt.Sprintf("terms are limited to %d characters", maxSynonymTermLength)}}
+		}
+		seen[term] = true
+		out = append(out, term)
+	}
+	if len(out) < 2 {
+		return nil, &ValidationError{Fields: map[string]string{"terms": "a synonym group needs at least two different terms"}}
+	}
+	return out, nil
+}
+
+func (s *SynonymService) List(ctx context.Context) ([]models.SynonymGroup, error) {
+	return s.repo.FindAll(ctx)
+}
+
+// Create adds a synonym group and reloads the search synonyms
+func (s *SynonymService) Create(ctx context.Context, terms []string) (*models.SynonymGroup, *SyncReport, error) {
+	terms, err := normalizeTerms(terms)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	group := &models.SynonymGroup{Terms: terms}
+	if err := s.repo.Create(ctx, group); err != nil {
+		return nil, nil, err
+	}
+	return group, s.Reload(ctx), nil
+}
+
+// Update replaces a group's terms and reloads the search synonyms
+func (s *SynonymService) Update(ctx context.Context, id string, terms []string) (*models.SynonymGroup, *SyncReport, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, nil, mongo.ErrNoDocuments
+	}
+	terms, err = normalizeTerms(terms)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	group, err := s.repo.UpdateTerms(ctx, oid, terms)
+	if err != nil {
+		return nil, nil, err
+	}
+	return group, s.Reload(ctx), nil
+}
+
+// Delete removes a group and reloads the search synonyms
+func (s *SynonymService) Delete(ctx context.Context, id string) (*SyncReport, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	if err := s.repo.Delete(ctx, oid); err != nil {
+		return nil, err
+	}
+	return s.Reload(ctx), nil
+}
+
+// Reload pushes the whole synonym list to Elasticsearch
+func (s *SynonymService) Reload(ctx context.Context) *SyncReport {
+	report := &SyncReport{}
+
+	groups, err := s.repo.FindAll(ctx)
+	switch {
+	case err != nil:
+		report.SearchError = err.Error()
+	case s.search == nil:
+		report.SearchError = "search index unavailable"
+	default:
+		if err := s.search.PutSynonyms(ctx, groups); err != nil {
+			report.SearchError = err.Error()
+		}
+	}
+
+	report.Synced = report.SearchError == ""
+	if !report.Synced {
+		fmt.Printf("⚠️  Search synonyms not reloaded: %s\n", report.SearchError)
+	}
+	return report
+}
+
+// Init seeds the default synonyms into an empty list, then loads the list
+// into Elasticsearch. It runs at startup.
+func (s *SynonymService) Init(ctx context.Context) error {
+	count, err := s.repo.Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		for _, terms := range defaultSynonyms {
+			if err := s.repo.Create(ctx, &models.SynonymGroup{Terms: terms}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if report := s.Reload(ctx); !report.Synced {
+		return fmt.Errorf("reload synonyms: %s", report.SearchError)
+	}
+	return nil
+}
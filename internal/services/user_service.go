@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/khusa-mahal/backend/internal/models"
+	"github.com/khusa-mahal/backend/internal/repository/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxSavedAddresses caps the size of a user's address book
+const maxSavedAddresses = 10
+
+// ErrAddressNotFound is returned for an address ID that isn't in the user's address book
+var ErrAddressNotFound = errors.New("address not found")
+
+// UserService manages the signed-in customer's profile and address book
+type UserService struct {
+	userRepo *mongodb.UserRepository
+}
+
+func NewUserService(userRepo *mongodb.UserRepository) *UserService {
+	return &UserService{userRepo: userRepo}
+}
+
+// ProfileInput is a partial profile update; nil fields are left unchanged
+type ProfileInput struct {
+	Name  *string `json:"name"`
+	Phone *string `json:"phone"`
+	Age   *int    `json:"age"`
+}
+
+// AddressInput is a new or replacement address book entry
+type AddressInput struct {
+	Label             string `json:"label"`
+	Street            string `json:"street"`
+	City              string `json:"city"`
+	State             string `json:"state"`
+	ZipCode           string `json:"zipCode"`
+	Country           string `json:"country"`
+	IsDefaultShipping bool   `json:"isDefaultShipping"`
+	IsDefaultBilling  bool   `json:"isDefaultBilling"`
+}
+
+func (in *AddressInput) validate() error {
+	in.Label = strings.TrimSpace(in.Label)
+	in.Street = strings.TrimSpace(in.Street)
+	in.City = strings.TrimSpace(in.City)
+	in.State = strings.TrimSpace(in.State)
+	in.ZipCode = strings.TrimSpace(in.ZipCode)
+	in.Country = strings.TrimSpace(in.Country)
+
+	fields := map[string]string{}
+	if in.Street == "" {
+		fields["street"] = "street is required"
+	}
+	if in.City == "" {
+		fields["city"] = "city is required"
+	}
+	if in.Country == "" {
+		fields["country"] = "country is required"
+	}
+	if len(in.Label) > 40 {
+		fields["label"] = "label must be at most 40 characters"
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+func (in *AddressInput) toSaved(id primitive.ObjectID) models.SavedAddress {
+	return models.SavedAddress{
+		ID:    id,
+		Label: in.Label,
+		Address: models.Address{
+			Street:  in.Street,
+			City:    in.City,
+			State:   in.State,
+			ZipCode: in.ZipCode,
+			Country: in.Country,
+		},
+		IsDefaultShipping: in.IsDefaultShipping,
+		IsDefaultBilling:  in.IsDefaultBilling,
+	}
+}
+
+func (s *UserService) getUser(ctx context.Context, userID string) (*models.User, error) {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	return s.userRepo.FindByID(ctx, oid)
+}
+
+// GetProfile returns the user's profile including their address book
+func (s *UserService) GetProfile(ctx context.Context, userID string) (*models.User, error) {
+	return s.getUser(ctx, userID)
+}
+
+// UpdateProfile applies a partial profile update
+func (s *UserService) UpdateProfile(ctx context.Context, userID string, input ProfileInput) (*models.User, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]string{}
+	update := bson.M{}
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" {
+			fields["name"] = "name cannot be empty"
+		}
+		update["name"] = name
+	}
+	if input.Phone != nil {
+		phone := strings.TrimSpace(*input.Phone)
+		if phone != "" && !validPhone(phone) {
+			fields["phone"] = "phone must contain 7 to 15 digits"
+		}
+		update["phone"] = phone
+	}
+	if input.Age != nil {
+		if *input.Age < 0 || *input.Age > 120 {
+			fields["age"] = "age must be between 0 and 120"
+		}
+		update["age"] = *input.Age
+	}
+	if len(fields) > 0 {
+		return nil, &ValidationError{Fields: fields}
+	}
+	if len(update) == 0 {
+		return user, nil
+	}
+
+	if err := s.userRepo.UpdateProfile(ctx, user.ID, update); err != nil {
+		return nil, err
+	}
+	return s.userRepo.FindByID(ctx, user.ID)
+}
+
+func validPhone(phone string) bool {
+	digits := 0
+	for i, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '+' && i == 0, r == ' ', r == '-':
+		default:
+			return false
+		}
+	}
+	return digits >= 7 && digits <= 15
+}
+
+// ListAddresses returns the user's address book
+func (s *UserService) ListAddresses(ctx context.Context, userID string) ([]models.SavedAddress, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Addresses == nil {
+		return []models.SavedAddress{}, nil
+	}
+	return user.Addresses, nil
+}
+
+// AddAddress adds an entry to the address book. The first address becomes
+// the default for both shipping and billing.
+func (s *UserService) AddAddress(ctx context.Context, userID string, input AddressInput) (*models.SavedAddress, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(user.Addresses) >= maxSavedAddresses {
+		return nil, &ValidationError{Fields: map[string]string{"addresses": "address book is full"}}
+	}
+
+	address := input.toSaved(primitive.NewObjectID())
+	addresses := append(user.Addresses, address)
+	normalizeDefaults(addresses, address.ID)
+
+	if err := s.userRepo.SetAddresses(ctx, user.ID, addresses); err != nil {
+		return nil, err
+	}
+	return &addresses[len(addresses)-1], nil
+}
+
+// UpdateAddress replaces an address book entry
+func (s *UserService) UpdateAddress(ctx context.Context, userID, addressID string, input AddressInput) (*models.SavedAddress, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	user, address, err := s.findAddress(ctx, userID, addressID)
+	if err != nil {
+		return nil, err
+	}
+
+	*address = input.toSaved(address.ID)
+	normalizeDefaults(user.Addresses, address.ID)
+
+	if err := s.userRepo.SetAddresses(ctx, user.ID, user.Addresses); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// DeleteAddress removes an address book entry. If it was a default, the
+// first remaining address takes its place.
+func (s *UserService) DeleteAddress(ctx context.Context, userID, addressID string) error {
+	user, address, err := s.findAddress(ctx, userID, addressID)
+	if err != nil {
+		return err
+	}
+
+	addresses := make([]models.SavedAddress, 0, len(user.Addresses)-1)
+	for _, a := range user.Addresses {
+		if a.ID != address.ID {
+			addresses = append(addresses, a)
+		}
+	}
+	normalizeDefaults(addresses, primitive.NilObjectID)
+
+	return s.userRepo.SetAddresses(ctx, user.ID, addresses)
+}
+
+func (s *UserService) findAddress(ctx context.Context, userID, addressID string) (*models.User, *models.SavedAddress, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	oid, err := primitive.ObjectIDFromHex(addressID)
+	if err != nil {
+		return nil, nil, ErrAddressNotFound
+	}
+	address := user.FindAddress(oid)
+	if address == nil {
+		return nil, nil, ErrAddressNotFound
+	}
+	return user, address, nil
+}
+
+// normalizeDefaults leaves exactly one default shipping and one default
+// billing address. A default set on changed wins over older ones; if no
+// address is marked, the first one is used.
+func normalizeDefaults(addresses []models.SavedAddress, changed primitive.ObjectID) {
+	if len(addresses) == 0 {
+		return
+	}
+
+	pick := func(isDefault func(*models.SavedAddress) *bool) {
+		winner := -1
+		for i := range addresses {
+			if *isDefault(&addresses[i]) && (winner == -1 || addresses[i].ID == changed) {
+				winner = i
+			}
+		}
+		if winner == -1 {
+			winner = 0
+		}
+		for i := range addresses {
+			*isDefault(&addresses[i]) = i == winner
+		}
+	}
+
+	pick(func(a *models.SavedAddress) *bool { return &a.IsDefaultShipping })
+	pick(func(a *models.SavedAddress) *bool { return &a.IsDefaultBilling })
+}